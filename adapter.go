@@ -0,0 +1,75 @@
+package tokenrate
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/KyberNetwork/tokenrate/common"
+)
+
+// FromETHUSDRateProvider adapts given ETHUSDRateProvider to Provider
+// answering ETH/USD queries only. It allows the wrappers of this library
+// written for Provider to be used with ETHUSDRateProvider.
+func FromETHUSDRateProvider(p ETHUSDRateProvider) ProviderContext {
+	return &ethUSDRateProvider{p: p}
+}
+
+type ethUSDRateProvider struct {
+	p ETHUSDRateProvider
+}
+
+func (a *ethUSDRateProvider) Rate(token, currency string, timestamp time.Time) (float64, error) {
+	return a.RateContext(context.Background(), token, currency, timestamp)
+}
+
+func (a *ethUSDRateProvider) RateContext(ctx context.Context, token, currency string, timestamp time.Time) (float64, error) {
+	if !strings.EqualFold(token, common.ETHID) {
+		return 0, errors.Wrapf(ErrUnsupportedToken, "%s only supports %s", a.p.Name(), common.ETHID)
+	}
+	if !strings.EqualFold(currency, common.USDID) {
+		return 0, errors.Wrapf(ErrUnsupportedCurrency, "%s only supports %s", a.p.Name(), common.USDID)
+	}
+	return USDRateContext(ctx, a.p, timestamp)
+}
+
+func (a *ethUSDRateProvider) Name() string {
+	return a.p.Name()
+}
+
+// ToETHUSDRateProvider adapts given Provider to ETHUSDRateProvider
+// querying given token and currency, which are the IDs of ETH and USD
+// understood by p.
+func ToETHUSDRateProvider(p Provider, token, currency string) ETHUSDRateProviderContext {
+	// unwrap instead of stacking adapters
+	if a, ok := p.(*ethUSDRateProvider); ok {
+		if pc, ok := a.p.(ETHUSDRateProviderContext); ok {
+			return pc
+		}
+	}
+	return &providerETHUSDRate{p: p, token: token, currency: currency}
+}
+
+type providerETHUSDRate struct {
+	p               Provider
+	token, currency string
+}
+
+func (a *providerETHUSDRate) USDRate(timestamp time.Time) (float64, error) {
+	return a.USDRateContext(context.Background(), timestamp)
+}
+
+func (a *providerETHUSDRate) USDRateContext(ctx context.Context, timestamp time.Time) (float64, error) {
+	return RateContext(ctx, a.p, a.token, a.currency, timestamp)
+}
+
+func (a *providerETHUSDRate) Name() string {
+	return a.p.Name()
+}
+
+// Unwrap returns the adapted Provider.
+func (a *providerETHUSDRate) Unwrap() Provider {
+	return a.p
+}
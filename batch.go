@@ -0,0 +1,101 @@
+package tokenrate
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateResult is the result of a single (token, currency) cell of a
+// batch query.
+type RateResult struct {
+	Rate float64
+	Err  error
+}
+
+// BatchResult is the result matrix of a batch query: BatchResult[i][j]
+// is the rate of tokens[i] in currencies[j].
+type BatchResult [][]RateResult
+
+// BatchProvider is the optional interface implemented by providers
+// that are able to query rates of many tokens in many currencies at
+// once. The returned error is only non-nil if the whole query failed,
+// failures of individual cells are reported in the result matrix.
+type BatchProvider interface {
+	BatchRate(ctx context.Context, tokens, currencies []string, timestamp time.Time) (BatchResult, error)
+	// Name return name of provider
+	Name() string
+}
+
+// BatchAdapter implements BatchProvider on top of a Provider by
+// running single queries with bounded concurrency.
+type BatchAdapter struct {
+	p           Provider
+	concurrency int
+}
+
+// NewBatchAdapter creates a new BatchAdapter that has at most
+// concurrency queries to p in flight.
+func NewBatchAdapter(p Provider, concurrency int) *BatchAdapter {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &BatchAdapter{p: p, concurrency: concurrency}
+}
+
+// AsBatchProvider returns p itself if it implements BatchProvider,
+// otherwise a BatchAdapter of p with given concurrency.
+func AsBatchProvider(p Provider, concurrency int) BatchProvider {
+	if bp, ok := p.(BatchProvider); ok {
+		return bp
+	}
+	return NewBatchAdapter(p, concurrency)
+}
+
+// BatchRate queries rates of all tokens in all currencies.
+func (a *BatchAdapter) BatchRate(ctx context.Context, tokens, currencies []string, timestamp time.Time) (BatchResult, error) {
+	var (
+		result = NewBatchResult(len(tokens), len(currencies))
+		sem    = make(chan struct{}, a.concurrency)
+		wg     sync.WaitGroup
+	)
+	for i, token := range tokens {
+		for j, currency := range currencies {
+			var (
+				i, j            = i, j
+				token, currency = token, currency
+			)
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				wg.Wait()
+				return nil, ctx.Err()
+			}
+			wg.Add(1)
+			go func() {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				rate, err := RateContext(ctx, a.p, token, currency, timestamp)
+				result[i][j] = RateResult{Rate: rate, Err: err}
+			}()
+		}
+	}
+	wg.Wait()
+	return result, nil
+}
+
+// Name return name of the adapted provider.
+func (a *BatchAdapter) Name() string {
+	return a.p.Name()
+}
+
+// NewBatchResult creates an empty result matrix of given dimension.
+func NewBatchResult(tokens, currencies int) BatchResult {
+	result := make(BatchResult, tokens)
+	for i := range result {
+		result[i] = make([]RateResult, currencies)
+	}
+	return result
+}
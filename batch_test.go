@@ -0,0 +1,61 @@
+package tokenrate
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockProvider struct {
+	name     string
+	rates    map[string]float64
+	inFlight int32
+	maxIn    int32
+}
+
+func (m *mockProvider) Rate(token, currency string, timestamp time.Time) (float64, error) {
+	n := atomic.AddInt32(&m.inFlight, 1)
+	defer atomic.AddInt32(&m.inFlight, -1)
+	for {
+		max := atomic.LoadInt32(&m.maxIn)
+		if n <= max || atomic.CompareAndSwapInt32(&m.maxIn, max, n) {
+			break
+		}
+	}
+	time.Sleep(time.Millisecond)
+	rate, ok := m.rates[token+"/"+currency]
+	if !ok {
+		return 0, ErrUnsupportedToken
+	}
+	return rate, nil
+}
+
+func (m *mockProvider) Name() string {
+	return m.name
+}
+
+func TestBatchAdapter(t *testing.T) {
+	p := &mockProvider{
+		name: "mock",
+		rates: map[string]float64{
+			"ETH/USD": 100,
+			"KNC/USD": 0.5,
+			"ETH/EUR": 90,
+		},
+	}
+	bp := AsBatchProvider(p, 2)
+	result, err := bp.BatchRate(context.Background(),
+		[]string{"ETH", "KNC"}, []string{"USD", "EUR"}, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 100.0, result[0][0].Rate)
+	assert.Equal(t, 90.0, result[0][1].Rate)
+	assert.Equal(t, 0.5, result[1][0].Rate)
+	assert.Equal(t, ErrUnsupportedToken, errors.Cause(result[1][1].Err))
+	assert.True(t, atomic.LoadInt32(&p.maxIn) <= 2)
+	assert.Equal(t, "mock", bp.Name())
+}
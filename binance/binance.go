@@ -0,0 +1,285 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/KyberNetwork/tokenrate"
+	"github.com/KyberNetwork/tokenrate/common"
+	"github.com/KyberNetwork/tokenrate/pkg/httpclient"
+)
+
+const (
+	defaultBaseURL = "https://api.binance.com"
+	klinesEndpoint = "%s/api/v3/klines"
+	// maxKlines is the maximum number of klines of a single request.
+	maxKlines = 1000
+	// invalidSymbolCode is the error code of Binance API for unknown
+	// symbol.
+	invalidSymbolCode = -1121
+)
+
+const (
+	oneMinute = "1m"
+	oneHour   = "1h"
+	oneDay    = "1d"
+)
+
+// intervals is the duration of each kline interval in use.
+var intervals = map[string]time.Duration{
+	oneMinute: time.Minute,
+	oneHour:   time.Hour,
+	oneDay:    24 * time.Hour,
+}
+
+// stableCurrencies maps the real world currencies to the stable coins
+// quoted on Binance.
+var stableCurrencies = map[string]string{
+	"USD": "USDT",
+}
+
+// Binance is the Binance implementation of Provider, on top of the
+// public klines endpoint. Tokens and currencies are the symbols of
+// Binance assets, e.g. ETH, BTC, USDT, USD is quoted in USDT.
+// Historical rates are the open price of the hour starting the day at
+// 00:00 UTC, the same sample RateRange returns for that time. Today
+// rate is the close price of the last minute.
+type Binance struct {
+	client  *http.Client
+	baseURL string
+}
+
+// New creates a new Binance instance.
+func New(opts ...httpclient.Option) *Binance {
+	cfg := httpclient.NewConfig(defaultBaseURL, opts...)
+	return &Binance{
+		client:  cfg.Client,
+		baseURL: cfg.BaseURL,
+	}
+}
+
+// Rate returns the rate of given token in given currency at given timestamp.
+func (b *Binance) Rate(token, currency string, timestamp time.Time) (float64, error) {
+	return b.RateContext(context.Background(), token, currency, timestamp)
+}
+
+// RateContext is like Rate but the request is bound to given context.
+func (b *Binance) RateContext(ctx context.Context, token, currency string, timestamp time.Time) (float64, error) {
+	if common.IsToday(timestamp) {
+		klines, err := b.klines(ctx, symbol(token, currency), oneMinute, time.Now().Add(-intervals[oneMinute]), time.Time{}, 1)
+		if err != nil {
+			return 0, err
+		}
+		if len(klines) == 0 {
+			return 0, errors.Wrapf(tokenrate.ErrUnsupportedTimestamp, "no kline of %s today", symbol(token, currency))
+		}
+		return klines[0].Close, nil
+	}
+	start := timestamp.UTC().Truncate(intervals[oneDay])
+	klines, err := b.klines(ctx, symbol(token, currency), oneHour, start, time.Time{}, 1)
+	if err != nil {
+		return 0, err
+	}
+	// the first kline is later than start if the symbol is listed later
+	if len(klines) == 0 || !klines[0].OpenTime.Equal(start) {
+		return 0, errors.Wrapf(tokenrate.ErrUnsupportedTimestamp, "no kline of %s at %s", symbol(token, currency), timestamp)
+	}
+	return klines[0].Open, nil
+}
+
+// USDRate returns the historical price of ETH.
+func (b *Binance) USDRate(timestamp time.Time) (float64, error) {
+	return b.USDRateContext(context.Background(), timestamp)
+}
+
+// USDRateContext is like USDRate but the request is bound to given context.
+func (b *Binance) USDRateContext(ctx context.Context, timestamp time.Time) (float64, error) {
+	return b.RateContext(ctx, common.ETHID, common.USDID, timestamp)
+}
+
+// RateRange returns the hourly open prices of given token in given
+// currency between from and to.
+func (b *Binance) RateRange(ctx context.Context, token, currency string, from, to time.Time) ([]tokenrate.RatePoint, error) {
+	klines, err := b.klineRange(ctx, symbol(token, currency), oneHour, from, to)
+	if err != nil {
+		return nil, err
+	}
+	points := make([]tokenrate.RatePoint, 0, len(klines))
+	for _, k := range klines {
+		points = append(points, tokenrate.RatePoint{Timestamp: k.OpenTime, Rate: k.Open})
+	}
+	return points, nil
+}
+
+// USDRateRange returns the ETH/USD rates between from and to.
+func (b *Binance) USDRateRange(ctx context.Context, from, to time.Time) ([]tokenrate.RatePoint, error) {
+	return b.RateRange(ctx, common.ETHID, common.USDID, from, to)
+}
+
+// Candles returns the daily candles of given token in given currency.
+// The volume is the traded volume in the quote asset.
+func (b *Binance) Candles(ctx context.Context, token, currency string, from, to time.Time) ([]tokenrate.Candle, error) {
+	day := intervals[oneDay]
+	klines, err := b.klineRange(ctx, symbol(token, currency), oneDay, from.UTC().Truncate(day), to.UTC().Truncate(day))
+	if err != nil {
+		return nil, err
+	}
+	candles := make([]tokenrate.Candle, 0, len(klines))
+	for _, k := range klines {
+		candles = append(candles, tokenrate.Candle{
+			Timestamp: k.OpenTime,
+			Open:      k.Open,
+			High:      k.High,
+			Low:       k.Low,
+			Close:     k.Close,
+			Volume:    k.QuoteVolume,
+		})
+	}
+	return candles, nil
+}
+
+// USDCandles returns the daily ETH/USD candles.
+func (b *Binance) USDCandles(ctx context.Context, from, to time.Time) ([]tokenrate.Candle, error) {
+	return b.Candles(ctx, common.ETHID, common.USDID, from, to)
+}
+
+// Name returns common.Binance.
+func (b *Binance) Name() string {
+	return common.Binance
+}
+
+// klineRange returns the klines opened between from and to, queried in
+// as many requests as needed.
+func (b *Binance) klineRange(ctx context.Context, symbol, interval string, from, to time.Time) ([]kline, error) {
+	var result []kline
+	for start := from; !start.After(to); {
+		klines, err := b.klines(ctx, symbol, interval, start, to, maxKlines)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, klines...)
+		if len(klines) < maxKlines {
+			break
+		}
+		start = klines[len(klines)-1].OpenTime.Add(intervals[interval])
+	}
+	return result, nil
+}
+
+// klines returns at most limit klines opened from start, and to end if
+// it is not zero.
+func (b *Binance) klines(ctx context.Context, symbol, interval string, start, end time.Time, limit int) ([]kline, error) {
+	q := url.Values{}
+	q.Add("symbol", symbol)
+	q.Add("interval", interval)
+	q.Add("startTime", strconv.FormatInt(toMs(start), 10))
+	if !end.IsZero() {
+		q.Add("endTime", strconv.FormatInt(toMs(end), 10))
+	}
+	q.Add("limit", strconv.Itoa(limit))
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(klinesEndpoint, b.baseURL), nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.URL.RawQuery = q.Encode()
+	rsp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+
+	switch rsp.StatusCode {
+	case http.StatusOK:
+	case http.StatusBadRequest:
+		var apiErr apiError
+		if err := json.NewDecoder(rsp.Body).Decode(&apiErr); err == nil && apiErr.Code == invalidSymbolCode {
+			return nil, errors.Wrapf(tokenrate.ErrUnsupportedToken, "symbol %s not found", symbol)
+		}
+		return nil, tokenrate.NewUpstreamError(rsp)
+	case http.StatusTeapot: // IP banned for exceeding the rate limit
+		return nil, &tokenrate.ErrRateLimited{RetryAfter: tokenrate.ParseRetryAfter(rsp.Header.Get("Retry-After"))}
+	default:
+		return nil, tokenrate.NewUpstreamError(rsp)
+	}
+	var klines []kline
+	if err := json.NewDecoder(rsp.Body).Decode(&klines); err != nil {
+		return nil, err
+	}
+	return klines, nil
+}
+
+// apiError is the error response of Binance API.
+type apiError struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+// kline is a candle of Binance klines response.
+type kline struct {
+	OpenTime    time.Time
+	Open        float64
+	High        float64
+	Low         float64
+	Close       float64
+	QuoteVolume float64
+}
+
+// UnmarshalJSON decodes a kline of the form [open time, "open", "high",
+// "low", "close", "volume", close time, "quote asset volume", ...].
+func (k *kline) UnmarshalJSON(data []byte) error {
+	var fields []json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if len(fields) < 8 {
+		return errors.Errorf("malformed kline: %s", data)
+	}
+	var openTime int64
+	if err := json.Unmarshal(fields[0], &openTime); err != nil {
+		return errors.Wrap(err, "malformed kline open time")
+	}
+	k.OpenTime = time.Unix(0, openTime*int64(time.Millisecond)).UTC()
+	for _, f := range []struct {
+		i int
+		v *float64
+	}{
+		{1, &k.Open},
+		{2, &k.High},
+		{3, &k.Low},
+		{4, &k.Close},
+		{7, &k.QuoteVolume},
+	} {
+		var s string
+		if err := json.Unmarshal(fields[f.i], &s); err != nil {
+			return errors.Wrapf(err, "malformed kline field %d", f.i)
+		}
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return errors.Wrapf(err, "malformed kline field %d", f.i)
+		}
+		*f.v = v
+	}
+	return nil
+}
+
+// symbol returns the Binance symbol of given pair, e.g. ETHUSDT.
+func symbol(token, currency string) string {
+	currency = strings.ToUpper(currency)
+	if stable, ok := stableCurrencies[currency]; ok {
+		currency = stable
+	}
+	return strings.ToUpper(token) + currency
+}
+
+func toMs(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
@@ -0,0 +1,171 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/tokenrate"
+	"github.com/KyberNetwork/tokenrate/pkg/httpclient"
+)
+
+// klineJSON returns a kline of Binance klines response.
+func klineJSON(openTime time.Time, open, high, low, close, quoteVolume float64) string {
+	return fmt.Sprintf(`[%d,"%f","%f","%f","%f","1.0",%d,"%f",10,"0.5","%f","0"]`,
+		toMs(openTime), open, high, low, close, toMs(openTime)+1, quoteVolume, quoteVolume/2)
+}
+
+func TestBinanceRate(t *testing.T) {
+	day := time.Date(2019, 10, 1, 0, 0, 0, 0, time.UTC)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v3/klines", r.URL.Path)
+		q := r.URL.Query()
+		switch q.Get("symbol") {
+		case "ETHUSDT":
+		case "KNCBTC":
+			require.Equal(t, "1h", q.Get("interval"))
+			_, _ = w.Write([]byte(`[]`))
+			return
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"code":-1121,"msg":"Invalid symbol."}`))
+			return
+		}
+		switch q.Get("interval") {
+		case "1h":
+			require.Equal(t, strconv.FormatInt(toMs(day), 10), q.Get("startTime"))
+			require.Equal(t, "1", q.Get("limit"))
+			_, _ = w.Write([]byte(`[` + klineJSON(day, 180, 185, 175, 182.5, 1000) + `]`))
+		case "1m":
+			_, _ = w.Write([]byte(`[` + klineJSON(time.Now(), 200, 201, 199, 200.5, 10) + `]`))
+		default:
+			w.WriteHeader(http.StatusTeapot)
+		}
+	}))
+	defer ts.Close()
+
+	b := New(httpclient.WithBaseURL(ts.URL))
+
+	rate, err := b.USDRate(day.Add(10 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 180.0, rate)
+
+	rate, err = b.RateContext(context.Background(), "eth", "usd", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 200.5, rate)
+
+	_, err = b.Rate("XYZ", "USD", day)
+	assert.Equal(t, tokenrate.ErrUnsupportedToken, errors.Cause(err))
+
+	_, err = b.Rate("KNC", "BTC", day)
+	assert.Equal(t, tokenrate.ErrUnsupportedTimestamp, errors.Cause(err))
+}
+
+func TestSymbol(t *testing.T) {
+	assert.Equal(t, "ETHUSDT", symbol("eth", "usd"))
+	assert.Equal(t, "KNCBTC", symbol("KNC", "BTC"))
+	assert.Equal(t, "ETHEUR", symbol("ETH", "EUR"))
+}
+
+// TestBinanceRangeAndCandles tests the klines pagination: a full page
+// of klines is followed by a request starting after its last kline.
+func TestBinanceRangeAndCandles(t *testing.T) {
+	var (
+		from     = time.Date(2019, 10, 1, 0, 0, 0, 0, time.UTC)
+		requests int
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		q := r.URL.Query()
+		require.Equal(t, "ETHUSDT", q.Get("symbol"))
+		switch q.Get("interval") {
+		case "1h":
+			// full pages of klines are followed by the next page
+			start, err := strconv.ParseInt(q.Get("startTime"), 10, 64)
+			require.NoError(t, err)
+			n := maxKlines
+			if start > toMs(from) {
+				n = 2
+			}
+			_, _ = w.Write([]byte(`[`))
+			for i := 0; i < n; i++ {
+				if i > 0 {
+					_, _ = w.Write([]byte(`,`))
+				}
+				openTime := time.Unix(0, start*int64(time.Millisecond)).Add(time.Duration(i) * time.Hour)
+				_, _ = w.Write([]byte(klineJSON(openTime, float64(i), 0, 0, 0, 0)))
+			}
+			_, _ = w.Write([]byte(`]`))
+		case "1d":
+			require.Equal(t, strconv.FormatInt(toMs(from), 10), q.Get("startTime"))
+			require.Equal(t, strconv.FormatInt(toMs(from.Add(24*time.Hour)), 10), q.Get("endTime"))
+			_, _ = w.Write([]byte(`[` + klineJSON(from, 180, 185, 175, 182.5, 1000) + `,` +
+				klineJSON(from.Add(24*time.Hour), 182.5, 190, 180, 188, 2000) + `]`))
+		}
+	}))
+	defer ts.Close()
+
+	b := New(httpclient.WithBaseURL(ts.URL))
+
+	points, err := b.USDRateRange(context.Background(), from, from.Add(2000*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, points, maxKlines+2)
+	assert.Equal(t, 2, requests)
+	assert.True(t, points[0].Timestamp.Equal(from))
+	assert.True(t, points[maxKlines].Timestamp.Equal(from.Add(maxKlines*time.Hour)))
+
+	candles, err := b.USDCandles(context.Background(), from.Add(time.Hour), from.Add(25*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []tokenrate.Candle{
+		{Timestamp: from, Open: 180, High: 185, Low: 175, Close: 182.5, Volume: 1000},
+		{Timestamp: from.Add(24 * time.Hour), Open: 182.5, High: 190, Low: 180, Close: 188, Volume: 2000},
+	}, candles)
+}
+
+func TestBinanceRateMatchesRange(t *testing.T) {
+	// the open price of each hour is its number of hours since epoch
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		require.Equal(t, "1h", q.Get("interval"))
+		start, err := strconv.ParseInt(q.Get("startTime"), 10, 64)
+		require.NoError(t, err)
+		limit, err := strconv.Atoi(q.Get("limit"))
+		require.NoError(t, err)
+		end := start + int64(limit-1)*3600*1000
+		if q.Get("endTime") != "" {
+			end, err = strconv.ParseInt(q.Get("endTime"), 10, 64)
+			require.NoError(t, err)
+		}
+		_, _ = w.Write([]byte(`[`))
+		for ms := start; ms <= end; ms += 3600 * 1000 {
+			if ms > start {
+				_, _ = w.Write([]byte(`,`))
+			}
+			hour := float64(ms / 3600 / 1000)
+			_, _ = w.Write([]byte(klineJSON(time.Unix(0, ms*int64(time.Millisecond)), hour, hour, hour, hour+1, 0)))
+		}
+		_, _ = w.Write([]byte(`]`))
+	}))
+	defer ts.Close()
+
+	var (
+		b   = New(httpclient.WithBaseURL(ts.URL))
+		day = time.Date(2019, 10, 1, 0, 0, 0, 0, time.UTC)
+	)
+	rate, err := b.USDRate(day.Add(15 * time.Hour))
+	require.NoError(t, err)
+	points, err := b.USDRateRange(context.Background(), day.Add(-12*time.Hour), day.Add(12*time.Hour))
+	require.NoError(t, err)
+	point, ok := tokenrate.NearestPoint(points, day)
+	require.True(t, ok)
+	assert.True(t, point.Timestamp.Equal(day))
+	assert.Equal(t, point.Rate, rate)
+}
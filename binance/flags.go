@@ -0,0 +1,18 @@
+package binance
+
+import (
+	"github.com/urfave/cli"
+
+	"github.com/KyberNetwork/tokenrate/common"
+	"github.com/KyberNetwork/tokenrate/pkg/httpclient"
+)
+
+// NewFlags return cli config for binance
+func NewFlags() []cli.Flag {
+	return httpclient.NewFlags(common.Binance, defaultBaseURL)
+}
+
+// NewBinanceFromContext return binance provider
+func NewBinanceFromContext(c *cli.Context) *Binance {
+	return New(httpclient.NewOptionsFromContext(c, common.Binance)...)
+}
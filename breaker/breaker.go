@@ -0,0 +1,194 @@
+// Package breaker provides a circuit breaker for rate providers, so an
+// unhealthy provider is skipped quickly instead of waiting out its
+// timeout on every query.
+package breaker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/KyberNetwork/tokenrate"
+)
+
+// ErrOpen is returned without querying the provider when its circuit
+// breaker is open.
+var ErrOpen = errors.New("circuit breaker is open")
+
+// State is the state of a circuit breaker.
+type State int
+
+const (
+	// Closed is the normal state, queries go through.
+	Closed State = iota
+	// Open is the state after too many consecutive failures, queries
+	// are rejected until cooldown passes.
+	Open
+	// HalfOpen is the state after cooldown, a single trial query goes
+	// through to decide whether to close or open again.
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// Options configures a circuit breaker.
+type Options struct {
+	// FailureThreshold is the number of consecutive failures to open
+	// the breaker.
+	FailureThreshold int
+	// Cooldown is the duration the breaker stays open before allowing
+	// a trial query.
+	Cooldown time.Duration
+	// OnStateChange is called on every state transition if not nil.
+	OnStateChange func(name string, from, to State)
+}
+
+// DefaultOptions returns the default Options.
+func DefaultOptions() Options {
+	return Options{
+		FailureThreshold: 5,
+		Cooldown:         time.Second * 30,
+	}
+}
+
+// Status is the snapshot of a circuit breaker, for reporting.
+type Status struct {
+	Name     string    `json:"name"`
+	State    string    `json:"state"`
+	Failures int       `json:"failures"`
+	OpenedAt time.Time `json:"opened_at,omitempty"`
+}
+
+// Breaker is a circuit breaker of a provider. A Breaker is safe for
+// concurrent use.
+type Breaker struct {
+	mu       sync.Mutex
+	name     string
+	opts     Options
+	state    State
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+// New creates a new closed Breaker of the provider with given name.
+func New(name string, opts Options) *Breaker {
+	if opts.FailureThreshold < 1 {
+		opts.FailureThreshold = 1
+	}
+	return &Breaker{name: name, opts: opts}
+}
+
+// Allow returns ErrOpen if a query must not go through now. Each
+// allowed query must be followed by a call to Record or RecordTimeout,
+// or Release if its result says nothing about the provider health.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case Open:
+		if time.Since(b.openedAt) < b.opts.Cooldown {
+			return ErrOpen
+		}
+		b.setState(HalfOpen)
+		b.trial = true
+		return nil
+	case HalfOpen:
+		if b.trial {
+			return ErrOpen
+		}
+		b.trial = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Record records the result of an allowed query. Only failures showing
+// the provider is unhealthy count, e.g an unsupported token does not.
+func (b *Breaker) Record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+	if err == nil || !tokenrate.IsRetryable(err) {
+		b.failures = 0
+		if b.state != Closed {
+			b.setState(Closed)
+		}
+		return
+	}
+	b.fail()
+}
+
+// RecordTimeout records an allowed query that did not answer before
+// its deadline, it counts as a failure.
+func (b *Breaker) RecordTimeout() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+	b.fail()
+}
+
+func (b *Breaker) fail() {
+	b.failures++
+	if b.state == HalfOpen || b.failures >= b.opts.FailureThreshold {
+		b.openedAt = time.Now()
+		if b.state != Open {
+			b.setState(Open)
+		}
+	}
+}
+
+// Release releases an allowed query without recording its result.
+func (b *Breaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
+
+// State returns the current state of the breaker.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Status returns the snapshot of the breaker.
+func (b *Breaker) Status() Status {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	status := Status{
+		Name:     b.name,
+		State:    b.state.String(),
+		Failures: b.failures,
+	}
+	if b.state != Closed {
+		status.OpenedAt = b.openedAt
+	}
+	return status
+}
+
+// Name returns the name of the provider of the breaker.
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+func (b *Breaker) setState(to State) {
+	from := b.state
+	b.state = to
+	if b.opts.OnStateChange != nil {
+		b.opts.OnStateChange(b.name, from, to)
+	}
+}
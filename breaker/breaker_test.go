@@ -0,0 +1,134 @@
+package breaker
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/tokenrate"
+)
+
+type switchProvider struct {
+	calls int
+	err   error
+}
+
+func (s *switchProvider) Rate(token, currency string, timestamp time.Time) (float64, error) {
+	s.calls++
+	if s.err != nil {
+		return 0, s.err
+	}
+	return 100, nil
+}
+
+func (s *switchProvider) Name() string {
+	return "switch"
+}
+
+func TestBreaker(t *testing.T) {
+	var (
+		transitions []string
+		opts        = Options{
+			FailureThreshold: 2,
+			Cooldown:         time.Millisecond * 50,
+			OnStateChange: func(name string, from, to State) {
+				transitions = append(transitions, from.String()+"->"+to.String())
+			},
+		}
+		p  = &switchProvider{err: &tokenrate.ErrUpstream{StatusCode: http.StatusBadGateway, Status: "502 Bad Gateway"}}
+		b  = New(p.Name(), opts)
+		bp = NewProvider(p, b)
+	)
+	for i := 0; i < 2; i++ {
+		_, err := bp.Rate("ETH", "USD", time.Now())
+		require.Error(t, err)
+	}
+	assert.Equal(t, Open, b.State())
+
+	// open breaker skips the provider
+	_, err := bp.Rate("ETH", "USD", time.Now())
+	assert.Equal(t, ErrOpen, errors.Cause(err))
+	assert.Equal(t, 2, p.calls)
+
+	// failed trial opens it again
+	time.Sleep(opts.Cooldown)
+	_, err = bp.Rate("ETH", "USD", time.Now())
+	require.Error(t, err)
+	assert.Equal(t, Open, b.State())
+	assert.Equal(t, 3, p.calls)
+
+	// successful trial closes it
+	time.Sleep(opts.Cooldown)
+	p.err = nil
+	rate, err := bp.Rate("ETH", "USD", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 100.0, rate)
+	assert.Equal(t, Closed, b.State())
+
+	assert.Equal(t, []string{
+		"closed->open",
+		"open->half-open",
+		"half-open->open",
+		"open->half-open",
+		"half-open->closed",
+	}, transitions)
+}
+
+func TestBreakerIgnoresUnsupported(t *testing.T) {
+	var (
+		p  = &switchProvider{err: tokenrate.ErrUnsupportedToken}
+		b  = New(p.Name(), Options{FailureThreshold: 1, Cooldown: time.Minute})
+		bp = NewProvider(p, b)
+	)
+	_, err := bp.Rate("ETH", "USD", time.Now())
+	assert.Equal(t, tokenrate.ErrUnsupportedToken, err)
+	assert.Equal(t, Closed, b.State())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p.err = &tokenrate.ErrRateLimited{}
+	_, err = bp.RateContext(ctx, "ETH", "USD", time.Now())
+	assert.Error(t, err)
+	assert.Equal(t, Closed, b.State())
+}
+
+type hungProvider struct{}
+
+func (hungProvider) Rate(token, currency string, timestamp time.Time) (float64, error) {
+	return hungProvider{}.RateContext(context.Background(), token, currency, timestamp)
+}
+
+func (hungProvider) RateContext(ctx context.Context, token, currency string, timestamp time.Time) (float64, error) {
+	<-ctx.Done()
+	return 0, ctx.Err()
+}
+
+func (hungProvider) Name() string {
+	return "hung"
+}
+
+func TestBreakerOpensOnFallbackTimeout(t *testing.T) {
+	var (
+		b = New("hung", Options{FailureThreshold: 3, Cooldown: time.Minute})
+		f = tokenrate.NewFallbackProvider(
+			[]tokenrate.Provider{NewProvider(hungProvider{}, b)},
+			tokenrate.WithTimeout(time.Millisecond*10),
+		)
+	)
+	for i := 0; i < 3; i++ {
+		_, err := f.Rate("ETH", "USD", time.Now())
+		require.Error(t, err)
+	}
+	assert.Equal(t, Open, b.State())
+	assert.Equal(t, 3, b.Status().Failures)
+
+	_, err := f.Rate("ETH", "USD", time.Now())
+	fallbackErr, ok := err.(*tokenrate.FallbackError)
+	require.True(t, ok)
+	assert.Equal(t, ErrOpen, errors.Cause(fallbackErr.Errors[0].Err))
+}
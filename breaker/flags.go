@@ -0,0 +1,38 @@
+package breaker
+
+import (
+	"github.com/urfave/cli"
+)
+
+const (
+	failureThresholdFlag = "breaker-failure-threshold"
+	cooldownFlag         = "breaker-cooldown"
+)
+
+// NewFlags return cli config for provider circuit breakers.
+func NewFlags() []cli.Flag {
+	opts := DefaultOptions()
+	return []cli.Flag{
+		cli.IntFlag{
+			Name:   failureThresholdFlag,
+			Usage:  "consecutive provider failures to open its circuit breaker",
+			EnvVar: "BREAKER_FAILURE_THRESHOLD",
+			Value:  opts.FailureThreshold,
+		},
+		cli.DurationFlag{
+			Name:   cooldownFlag,
+			Usage:  "duration an open circuit breaker skips its provider before a trial query",
+			EnvVar: "BREAKER_COOLDOWN",
+			Value:  opts.Cooldown,
+		},
+	}
+}
+
+// NewOptionsFromContext return circuit breaker options configured by
+// cli flags.
+func NewOptionsFromContext(c *cli.Context) Options {
+	opts := DefaultOptions()
+	opts.FailureThreshold = c.Int(failureThresholdFlag)
+	opts.Cooldown = c.Duration(cooldownFlag)
+	return opts
+}
@@ -0,0 +1,62 @@
+package breaker
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/KyberNetwork/tokenrate"
+	"github.com/KyberNetwork/tokenrate/common"
+)
+
+// Provider is a tokenrate.Provider guarded by a circuit breaker.
+type Provider struct {
+	p tokenrate.Provider
+	b *Breaker
+}
+
+// NewProvider creates a Provider of p guarded by b.
+func NewProvider(p tokenrate.Provider, b *Breaker) *Provider {
+	return &Provider{p: p, b: b}
+}
+
+// NewETHUSDRateProvider creates an ETHUSDRateProvider of p guarded by b.
+func NewETHUSDRateProvider(p tokenrate.ETHUSDRateProvider, b *Breaker) tokenrate.ETHUSDRateProviderContext {
+	return tokenrate.ToETHUSDRateProvider(NewProvider(tokenrate.FromETHUSDRateProvider(p), b), common.ETHID, common.USDID)
+}
+
+// Rate queries the wrapped provider unless the breaker is open.
+func (bp *Provider) Rate(token, currency string, timestamp time.Time) (float64, error) {
+	return bp.RateContext(context.Background(), token, currency, timestamp)
+}
+
+// RateContext is like Rate but the query is bound to given context.
+func (bp *Provider) RateContext(ctx context.Context, token, currency string, timestamp time.Time) (float64, error) {
+	if err := bp.b.Allow(); err != nil {
+		return 0, errors.Wrap(err, bp.p.Name())
+	}
+	rate, err := tokenrate.RateContext(ctx, bp.p, token, currency, timestamp)
+	switch {
+	case err != nil && ctx.Err() == context.Canceled:
+		// the caller gave up, it says nothing about provider health
+		bp.b.Release()
+	case err != nil && ctx.Err() == context.DeadlineExceeded:
+		// the deadline may be a per-provider timeout applied by a
+		// composite provider, a hung provider must open the breaker
+		bp.b.RecordTimeout()
+	default:
+		bp.b.Record(err)
+	}
+	return rate, err
+}
+
+// Name return name of the wrapped provider.
+func (bp *Provider) Name() string {
+	return bp.p.Name()
+}
+
+// Breaker returns the circuit breaker of the provider.
+func (bp *Provider) Breaker() *Breaker {
+	return bp.b
+}
@@ -0,0 +1,138 @@
+package cache
+
+import (
+	"container/list"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Backend is the storage of cached rates. A Backend must be safe for
+// concurrent use.
+type Backend interface {
+	// Get returns the rate stored with given key, false if the key
+	// does not exist or is expired.
+	Get(key string) (float64, bool, error)
+	// Set stores the rate with given key, the entry expires after
+	// ttl, zero ttl means it never expires.
+	Set(key string, rate float64, ttl time.Duration) error
+}
+
+// Memory is the in-process Backend keeping at most size entries, the
+// least recently used entry is evicted when it is full.
+type Memory struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type memoryEntry struct {
+	key     string
+	rate    float64
+	expires time.Time
+}
+
+// NewMemory creates a new Memory backend with given maximum size.
+func NewMemory(size int) *Memory {
+	if size < 1 {
+		size = 1
+	}
+	return &Memory{
+		size:  size,
+		ll:    list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// Get returns the rate stored with given key.
+func (m *Memory) Get(key string) (float64, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	el, ok := m.items[key]
+	if !ok {
+		return 0, false, nil
+	}
+	entry := el.Value.(*memoryEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		m.ll.Remove(el)
+		delete(m.items, key)
+		return 0, false, nil
+	}
+	m.ll.MoveToFront(el)
+	return entry.rate, true, nil
+}
+
+// Set stores the rate with given key.
+func (m *Memory) Set(key string, rate float64, ttl time.Duration) error {
+	var expires time.Time
+	if ttl > 0 {
+		expires = time.Now().Add(ttl)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if el, ok := m.items[key]; ok {
+		entry := el.Value.(*memoryEntry)
+		entry.rate, entry.expires = rate, expires
+		m.ll.MoveToFront(el)
+		return nil
+	}
+	m.items[key] = m.ll.PushFront(&memoryEntry{key: key, rate: rate, expires: expires})
+	for m.ll.Len() > m.size {
+		el := m.ll.Back()
+		m.ll.Remove(el)
+		delete(m.items, el.Value.(*memoryEntry).key)
+	}
+	return nil
+}
+
+// Len returns the number of entries in the cache, including the
+// expired ones not evicted yet.
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.ll.Len()
+}
+
+// RedisClient is the subset of Redis commands needed by Redis backend.
+// It is easily implemented on top of any Redis compatible client.
+type RedisClient interface {
+	// Get returns the value of given key, false if it does not exist.
+	Get(key string) (string, bool, error)
+	// Set stores given value with given key, zero ttl means no expiry.
+	Set(key, value string, ttl time.Duration) error
+}
+
+// Redis is the Backend storing rates in a Redis compatible store. The
+// size bound is the responsibility of the store, e.g with
+// maxmemory-policy allkeys-lru.
+type Redis struct {
+	client RedisClient
+	prefix string
+}
+
+// NewRedis creates a new Redis backend, all keys are prefixed with
+// given prefix.
+func NewRedis(client RedisClient, prefix string) *Redis {
+	return &Redis{client: client, prefix: prefix}
+}
+
+// Get returns the rate stored with given key.
+func (r *Redis) Get(key string) (float64, bool, error) {
+	v, ok, err := r.client.Get(r.prefix + key)
+	if err != nil || !ok {
+		return 0, false, err
+	}
+	rate, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, false, errors.Wrapf(err, "invalid cached rate %q", v)
+	}
+	return rate, true, nil
+}
+
+// Set stores the rate with given key.
+func (r *Redis) Set(key string, rate float64, ttl time.Duration) error {
+	return r.client.Set(r.prefix+key, strconv.FormatFloat(rate, 'g', -1, 64), ttl)
+}
@@ -0,0 +1,117 @@
+// Package cache provides a caching wrapper for rate providers.
+// Historical rates never change so they are cached for a long time,
+// today rates are cached for a short time.
+package cache
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/KyberNetwork/tokenrate"
+	"github.com/KyberNetwork/tokenrate/common"
+)
+
+const (
+	// DefaultCurrentTTL is the default time to live of today rates.
+	DefaultCurrentTTL = time.Minute * 5
+	// DefaultSize is the default size of Memory backend.
+	DefaultSize = 10000
+)
+
+// Options configures the caching behaviour.
+type Options struct {
+	// CurrentTTL is the time to live of today rates.
+	CurrentTTL time.Duration
+	// HistoricalTTL is the time to live of historical rates, zero
+	// means they never expire.
+	HistoricalTTL time.Duration
+}
+
+// DefaultOptions returns the default Options.
+func DefaultOptions() Options {
+	return Options{
+		CurrentTTL:    DefaultCurrentTTL,
+		HistoricalTTL: 0,
+	}
+}
+
+// Stats is the statistics of a cache.
+type Stats struct {
+	Hits   uint64
+	Misses uint64
+	// Errors is the number of backend failures, the queries are
+	// forwarded to the provider in that case.
+	Errors uint64
+}
+
+// Provider is a tokenrate.Provider caching the rates of the wrapped
+// provider.
+type Provider struct {
+	// accessed atomically, keep them first for 64-bit alignment
+	hits, misses, errors uint64
+
+	p       tokenrate.Provider
+	backend Backend
+	opts    Options
+}
+
+// New creates a new caching Provider of p storing rates in backend.
+func New(p tokenrate.Provider, backend Backend, opts Options) *Provider {
+	return &Provider{p: p, backend: backend, opts: opts}
+}
+
+// NewETHUSDRateProvider creates a caching ETHUSDRateProvider of p.
+func NewETHUSDRateProvider(p tokenrate.ETHUSDRateProvider, backend Backend, opts Options) tokenrate.ETHUSDRateProviderContext {
+	return tokenrate.ToETHUSDRateProvider(New(tokenrate.FromETHUSDRateProvider(p), backend, opts), common.ETHID, common.USDID)
+}
+
+// Rate returns the cached rate if available, otherwise it queries the
+// wrapped provider.
+func (c *Provider) Rate(token, currency string, timestamp time.Time) (float64, error) {
+	return c.RateContext(context.Background(), token, currency, timestamp)
+}
+
+// RateContext is like Rate but the query is bound to given context.
+func (c *Provider) RateContext(ctx context.Context, token, currency string, timestamp time.Time) (float64, error) {
+	date := common.TimeToDateString(timestamp.UTC())
+	key := strings.Join([]string{c.p.Name(), token, currency, date}, "|")
+	rate, ok, err := c.backend.Get(key)
+	switch {
+	case err != nil:
+		atomic.AddUint64(&c.errors, 1)
+	case ok:
+		atomic.AddUint64(&c.hits, 1)
+		return rate, nil
+	default:
+		atomic.AddUint64(&c.misses, 1)
+	}
+
+	rate, err = tokenrate.RateContext(ctx, c.p, token, currency, timestamp)
+	if err != nil {
+		return 0, err
+	}
+	ttl := c.opts.HistoricalTTL
+	if date == common.TimeToDateString(time.Now().UTC()) {
+		ttl = c.opts.CurrentTTL
+	}
+	if err = c.backend.Set(key, rate, ttl); err != nil {
+		atomic.AddUint64(&c.errors, 1)
+	}
+	return rate, nil
+}
+
+// Name return name of the wrapped provider.
+func (c *Provider) Name() string {
+	return c.p.Name()
+}
+
+// Stats returns the statistics of the cache.
+func (c *Provider) Stats() Stats {
+	return Stats{
+		Hits:   atomic.LoadUint64(&c.hits),
+		Misses: atomic.LoadUint64(&c.misses),
+		Errors: atomic.LoadUint64(&c.errors),
+	}
+}
@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/tokenrate/common"
+)
+
+type countingProvider struct {
+	calls int
+}
+
+func (c *countingProvider) Rate(token, currency string, timestamp time.Time) (float64, error) {
+	c.calls++
+	return float64(c.calls), nil
+}
+
+func (c *countingProvider) USDRate(timestamp time.Time) (float64, error) {
+	return c.Rate(common.ETHID, common.USDID, timestamp)
+}
+
+func (c *countingProvider) Name() string {
+	return "counting"
+}
+
+type mapRedis struct {
+	mu   sync.Mutex
+	data map[string]string
+	ttls map[string]time.Duration
+}
+
+func (m *mapRedis) Get(key string) (string, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.data[key]
+	return v, ok, nil
+}
+
+func (m *mapRedis) Set(key, value string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[key] = value
+	m.ttls[key] = ttl
+	return nil
+}
+
+func TestProvider(t *testing.T) {
+	var (
+		p         = &countingProvider{}
+		c         = New(p, NewMemory(DefaultSize), Options{CurrentTTL: time.Millisecond * 50})
+		yesterday = time.Now().AddDate(0, 0, -1)
+	)
+	rate, err := c.Rate("ETH", "USD", yesterday)
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, rate)
+	rate, err = c.Rate("ETH", "USD", yesterday)
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, rate)
+
+	rate, err = c.Rate("ETH", "USD", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, rate)
+	rate, err = c.Rate("ETH", "USD", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, rate)
+
+	// today rate expires
+	time.Sleep(time.Millisecond * 100)
+	rate, err = c.Rate("ETH", "USD", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, rate)
+
+	// historical rate does not
+	rate, err = c.Rate("ETH", "USD", yesterday)
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, rate)
+
+	assert.Equal(t, Stats{Hits: 3, Misses: 3}, c.Stats())
+}
+
+func TestMemoryEviction(t *testing.T) {
+	m := NewMemory(2)
+	require.NoError(t, m.Set("a", 1, 0))
+	require.NoError(t, m.Set("b", 2, 0))
+	_, ok, _ := m.Get("a")
+	assert.True(t, ok)
+	require.NoError(t, m.Set("c", 3, 0))
+
+	assert.Equal(t, 2, m.Len())
+	_, ok, _ = m.Get("b")
+	assert.False(t, ok, "least recently used entry should be evicted")
+	rate, ok, _ := m.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1.0, rate)
+}
+
+func TestRedisBackend(t *testing.T) {
+	var (
+		client = &mapRedis{data: make(map[string]string), ttls: make(map[string]time.Duration)}
+		p      = &countingProvider{}
+		c      = NewETHUSDRateProvider(p, NewRedis(client, "tokenrate:"), DefaultOptions())
+	)
+	for i := 0; i < 2; i++ {
+		rate, err := c.USDRate(time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, 1.0, rate)
+	}
+	key := "tokenrate:counting|ETH|USD|" + common.TimeToDateString(time.Now().UTC())
+	assert.Equal(t, "1", client.data[key])
+	assert.Equal(t, DefaultCurrentTTL, client.ttls[key])
+	assert.Equal(t, 1, p.calls)
+}
@@ -0,0 +1,37 @@
+package tokenrate
+
+import (
+	"context"
+	"time"
+)
+
+// Candle is the open, high, low, close prices and the traded volume of
+// a period.
+type Candle struct {
+	// Timestamp is the start of the period.
+	Timestamp time.Time `json:"timestamp"`
+	Open      float64   `json:"open"`
+	High      float64   `json:"high"`
+	Low       float64   `json:"low"`
+	Close     float64   `json:"close"`
+	// Volume is the traded volume in the quote currency.
+	Volume float64 `json:"volume"`
+}
+
+// CandleProvider is the optional interface implemented by providers
+// that are able to return daily candles.
+type CandleProvider interface {
+	// Candles returns the daily candles of given token in given
+	// currency of the days from from to to, inclusive.
+	Candles(ctx context.Context, token, currency string, from, to time.Time) ([]Candle, error)
+	// Name return name of provider
+	Name() string
+}
+
+// ETHUSDCandleProvider is the optional interface implemented by
+// ETHUSDRateProvider that are able to return daily ETH/USD candles.
+type ETHUSDCandleProvider interface {
+	USDCandles(ctx context.Context, from, to time.Time) ([]Candle, error)
+	// Name return name of provider
+	Name() string
+}
@@ -0,0 +1,114 @@
+// Package coalesce provides a wrapper for rate providers sharing one
+// upstream query among concurrent identical queries.
+package coalesce
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KyberNetwork/tokenrate"
+	"github.com/KyberNetwork/tokenrate/common"
+)
+
+// call is an in-flight query shared by its waiters.
+type call struct {
+	done    chan struct{}
+	rate    float64
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// Group coalesces concurrent calls with the same key into one. Unlike
+// a plain singleflight, a waiter giving up does not fail the others:
+// the shared call is only cancelled when all its waiters are gone.
+type Group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// Do runs fn once for all concurrent callers with given key and
+// returns its result to each of them. The returned bool reports
+// whether the result was shared with a call started by another caller.
+func (g *Group) Do(ctx context.Context, key string, fn func(ctx context.Context) (float64, error)) (float64, bool, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+	c, shared := g.calls[key]
+	if !shared {
+		callCtx, cancel := context.WithCancel(context.Background())
+		c = &call{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = c
+		go g.run(callCtx, key, c, fn)
+	}
+	c.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.rate, shared, c.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		c.waiters--
+		if c.waiters == 0 {
+			c.cancel()
+			// later callers must not join the cancelled call
+			if g.calls[key] == c {
+				delete(g.calls, key)
+			}
+		}
+		g.mu.Unlock()
+		return 0, shared, ctx.Err()
+	}
+}
+
+func (g *Group) run(ctx context.Context, key string, c *call, fn func(ctx context.Context) (float64, error)) {
+	c.rate, c.err = fn(ctx)
+	c.cancel()
+	g.mu.Lock()
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+	g.mu.Unlock()
+	close(c.done)
+}
+
+// Provider is a tokenrate.Provider coalescing concurrent identical
+// queries of (token, currency, date) to the wrapped provider.
+type Provider struct {
+	p tokenrate.Provider
+	g Group
+}
+
+// New creates a coalescing Provider of p.
+func New(p tokenrate.Provider) *Provider {
+	return &Provider{p: p}
+}
+
+// NewETHUSDRateProvider creates a coalescing ETHUSDRateProvider of p.
+func NewETHUSDRateProvider(p tokenrate.ETHUSDRateProvider) tokenrate.ETHUSDRateProviderContext {
+	return tokenrate.ToETHUSDRateProvider(New(tokenrate.FromETHUSDRateProvider(p)), common.ETHID, common.USDID)
+}
+
+// Rate queries the wrapped provider or joins an identical in-flight
+// query.
+func (cp *Provider) Rate(token, currency string, timestamp time.Time) (float64, error) {
+	return cp.RateContext(context.Background(), token, currency, timestamp)
+}
+
+// RateContext is like Rate but the wait is bound to given context.
+func (cp *Provider) RateContext(ctx context.Context, token, currency string, timestamp time.Time) (float64, error) {
+	key := strings.Join([]string{cp.p.Name(), token, currency, common.TimeToDateString(timestamp.UTC())}, "|")
+	rate, _, err := cp.g.Do(ctx, key, func(ctx context.Context) (float64, error) {
+		return tokenrate.RateContext(ctx, cp.p, token, currency, timestamp)
+	})
+	return rate, err
+}
+
+// Name return name of the wrapped provider.
+func (cp *Provider) Name() string {
+	return cp.p.Name()
+}
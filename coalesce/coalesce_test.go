@@ -0,0 +1,83 @@
+package coalesce
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type slowProvider struct {
+	calls   int32
+	release chan struct{}
+}
+
+func (s *slowProvider) Rate(token, currency string, timestamp time.Time) (float64, error) {
+	atomic.AddInt32(&s.calls, 1)
+	<-s.release
+	return 100, nil
+}
+
+func (s *slowProvider) Name() string {
+	return "slow"
+}
+
+func TestProviderCoalesce(t *testing.T) {
+	var (
+		p  = &slowProvider{release: make(chan struct{})}
+		cp = New(p)
+		wg sync.WaitGroup
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rate, err := cp.Rate("ETH", "USD", time.Now())
+			assert.NoError(t, err)
+			assert.Equal(t, 100.0, rate)
+		}()
+	}
+	time.Sleep(time.Millisecond * 20)
+	close(p.release)
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&p.calls))
+
+	// different date is a different query
+	_, err := cp.Rate("ETH", "USD", time.Now().AddDate(0, 0, -1))
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&p.calls))
+}
+
+func TestGroupWaiterCancelled(t *testing.T) {
+	var (
+		g       Group
+		release = make(chan struct{})
+		started = make(chan struct{})
+		result  = make(chan float64)
+	)
+	fn := func(ctx context.Context) (float64, error) {
+		close(started)
+		<-release
+		return 1, nil
+	}
+	go func() {
+		rate, _, err := g.Do(context.Background(), "key", fn)
+		assert.NoError(t, err)
+		result <- rate
+	}()
+	<-started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, shared, err := g.Do(ctx, "key", fn)
+	assert.True(t, shared)
+	assert.Equal(t, context.Canceled, err)
+
+	// the first waiter is not affected
+	close(release)
+	assert.Equal(t, 1.0, <-result)
+}
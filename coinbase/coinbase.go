@@ -0,0 +1,133 @@
+package coinbase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/KyberNetwork/tokenrate"
+	"github.com/KyberNetwork/tokenrate/common"
+	"github.com/KyberNetwork/tokenrate/pkg/httpclient"
+)
+
+const (
+	defaultBaseURL  = "https://api.exchange.coinbase.com"
+	candlesEndpoint = "%s/products/%s/candles"
+	tickerEndpoint  = "%s/products/%s/ticker"
+	// dailyGranularity is the granularity of daily candles in seconds.
+	dailyGranularity = 86400
+	day              = 24 * time.Hour
+)
+
+// Coinbase is the Coinbase Exchange implementation of Provider. Tokens
+// and currencies are the Coinbase asset symbols, e.g. ETH, USD, EUR.
+// Historical rates are the close price of the day, today rate is the
+// last trade price.
+type Coinbase struct {
+	client  *http.Client
+	baseURL string
+}
+
+// New creates a new Coinbase instance.
+func New(opts ...httpclient.Option) *Coinbase {
+	cfg := httpclient.NewConfig(defaultBaseURL, opts...)
+	return &Coinbase{
+		client:  cfg.Client,
+		baseURL: cfg.BaseURL,
+	}
+}
+
+type tickerResponse struct {
+	Price string `json:"price"`
+}
+
+// Rate returns the rate of given token in given currency at given timestamp.
+func (cb *Coinbase) Rate(token, currency string, timestamp time.Time) (float64, error) {
+	return cb.RateContext(context.Background(), token, currency, timestamp)
+}
+
+// RateContext is like Rate but the request is bound to given context.
+func (cb *Coinbase) RateContext(ctx context.Context, token, currency string, timestamp time.Time) (float64, error) {
+	product := productID(token, currency)
+	if common.IsToday(timestamp) {
+		var ticker tickerResponse
+		if err := cb.get(ctx, fmt.Sprintf(tickerEndpoint, cb.baseURL, product), nil, &ticker); err != nil {
+			return 0, err
+		}
+		rate, err := strconv.ParseFloat(ticker.Price, 64)
+		if err != nil {
+			return 0, errors.Wrapf(err, "malformed price %q", ticker.Price)
+		}
+		return rate, nil
+	}
+
+	start := timestamp.UTC().Truncate(day)
+	q := url.Values{}
+	q.Add("granularity", strconv.Itoa(dailyGranularity))
+	q.Add("start", start.Format(time.RFC3339))
+	q.Add("end", start.Add(day).Format(time.RFC3339))
+	// each candle is [time, low, high, open, close, volume], newest first
+	var candles [][6]float64
+	if err := cb.get(ctx, fmt.Sprintf(candlesEndpoint, cb.baseURL, product), q, &candles); err != nil {
+		return 0, err
+	}
+	for _, c := range candles {
+		if int64(c[0]) == start.Unix() {
+			return c[4], nil
+		}
+	}
+	return 0, errors.Wrapf(tokenrate.ErrUnsupportedTimestamp, "no candle of %s at %s", product, common.TimeToDateString(start))
+}
+
+// USDRate returns the historical price of ETH.
+func (cb *Coinbase) USDRate(timestamp time.Time) (float64, error) {
+	return cb.USDRateContext(context.Background(), timestamp)
+}
+
+// USDRateContext is like USDRate but the request is bound to given context.
+func (cb *Coinbase) USDRateContext(ctx context.Context, timestamp time.Time) (float64, error) {
+	return cb.RateContext(ctx, common.ETHID, common.USDID, timestamp)
+}
+
+// Name returns common.Coinbase.
+func (cb *Coinbase) Name() string {
+	return common.Coinbase
+}
+
+// get sends a GET request to given endpoint and decodes the JSON
+// response to v.
+func (cb *Coinbase) get(ctx context.Context, endpoint string, q url.Values, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Add("Accept", "application/json")
+	req.URL.RawQuery = q.Encode()
+	rsp, err := cb.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+
+	switch rsp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return errors.Wrapf(tokenrate.ErrUnsupportedToken, "%s not found", req.URL.Path)
+	default:
+		return tokenrate.NewUpstreamError(rsp)
+	}
+	return json.NewDecoder(rsp.Body).Decode(v)
+}
+
+// productID returns the Coinbase product of given pair, e.g. ETH-USD.
+func productID(token, currency string) string {
+	return strings.ToUpper(token) + "-" + strings.ToUpper(currency)
+}
@@ -0,0 +1,66 @@
+package coinbase
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/tokenrate"
+	"github.com/KyberNetwork/tokenrate/pkg/httpclient"
+)
+
+func TestCoinbase(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/products/ETH-USD/ticker":
+			_, _ = w.Write([]byte(`{"trade_id":1,"price":"200.50","size":"0.1","time":"2019-10-01T00:00:00Z"}`))
+		case "/products/ETH-USD/candles":
+			q := r.URL.Query()
+			require.Equal(t, "86400", q.Get("granularity"))
+			require.Equal(t, "2019-10-01T00:00:00Z", q.Get("start"))
+			require.Equal(t, "2019-10-02T00:00:00Z", q.Get("end"))
+			_, _ = w.Write([]byte(`[[1569974400,181,190,182.5,188,1000],[1569888000,175,185,180,182.5,2000]]`))
+		case "/products/KNC-EUR/candles":
+			_, _ = w.Write([]byte(`[]`))
+		case "/products/ETH-EUR/ticker":
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"NotFound"}`))
+		}
+	}))
+	defer ts.Close()
+
+	cb := New(httpclient.WithBaseURL(ts.URL))
+
+	rate, err := cb.USDRate(time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 200.5, rate)
+
+	rate, err = cb.RateContext(context.Background(), "eth", "usd", time.Date(2019, 10, 1, 10, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, 182.5, rate)
+
+	_, err = cb.Rate("KNC", "EUR", time.Date(2019, 10, 1, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, tokenrate.ErrUnsupportedTimestamp, errors.Cause(err))
+
+	_, err = cb.Rate("XYZ", "USD", time.Now())
+	assert.Equal(t, tokenrate.ErrUnsupportedToken, errors.Cause(err))
+
+	_, err = cb.Rate("ETH", "EUR", time.Now())
+	retryAfter, ok := tokenrate.RetryAfter(err)
+	require.True(t, ok)
+	assert.Equal(t, 2*time.Second, retryAfter)
+}
+
+func TestProductID(t *testing.T) {
+	assert.Equal(t, "ETH-USD", productID("eth", "usd"))
+	assert.Equal(t, "BTC-EUR", productID("BTC", "EUR"))
+}
@@ -0,0 +1,18 @@
+package coinbase
+
+import (
+	"github.com/urfave/cli"
+
+	"github.com/KyberNetwork/tokenrate/common"
+	"github.com/KyberNetwork/tokenrate/pkg/httpclient"
+)
+
+// NewFlags return cli config for coinbase
+func NewFlags() []cli.Flag {
+	return httpclient.NewFlags(common.Coinbase, defaultBaseURL)
+}
+
+// NewCoinbaseFromContext return coinbase provider
+func NewCoinbaseFromContext(c *cli.Context) *Coinbase {
+	return New(httpclient.NewOptionsFromContext(c, common.Coinbase)...)
+}
@@ -0,0 +1,53 @@
+package coingecko
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/KyberNetwork/tokenrate"
+	"github.com/KyberNetwork/tokenrate/common"
+)
+
+// defaultBatchConcurrency is the number of concurrent single queries
+// for historical batch queries, which /simple/price does not support.
+const defaultBatchConcurrency = 4
+
+// BatchRate returns the rates of given tokens in given currencies. Today
+// rates are queried in a single request to /simple/price, historical
+// rates fall back to concurrent single queries.
+func (cg *CoinGecko) BatchRate(ctx context.Context, tokens, currencies []string, timestamp time.Time) (tokenrate.BatchResult, error) {
+	if !common.IsToday(timestamp) {
+		return tokenrate.NewBatchAdapter(cg, defaultBatchConcurrency).BatchRate(ctx, tokens, currencies, timestamp)
+	}
+
+	q := url.Values{}
+	q.Add("ids", strings.Join(tokens, ","))
+	q.Add("vs_currencies", strings.Join(currencies, ","))
+	var prices = make(map[string]map[string]float64)
+	if err := cg.get(ctx, fmt.Sprintf(simplePriceEndpoint, cg.baseURL), q, &prices); err != nil {
+		return nil, err
+	}
+
+	result := tokenrate.NewBatchResult(len(tokens), len(currencies))
+	for i, token := range tokens {
+		tokenPrices, found := prices[token]
+		for j, currency := range currencies {
+			if !found {
+				result[i][j].Err = errors.Wrapf(tokenrate.ErrUnsupportedToken, "coin %q not found", token)
+				continue
+			}
+			rate, ok := tokenPrices[currency]
+			if !ok {
+				result[i][j].Err = errors.Wrapf(tokenrate.ErrUnsupportedCurrency, "currency %q not found in market data", currency)
+				continue
+			}
+			result[i][j].Rate = rate
+		}
+	}
+	return result, nil
+}
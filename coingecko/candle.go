@@ -0,0 +1,172 @@
+package coingecko
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/KyberNetwork/tokenrate"
+)
+
+const ohlcEndpoint = "%s/coins/%s/ohlc"
+
+const (
+	day = 24 * time.Hour
+	// ohlcMaxAge is the maximum age of candles taken from /ohlc, older
+	// candles are 4 days long and can not make daily candles.
+	ohlcMaxAge = 30 * day
+	// hourlyChartMaxRange is the maximum range of market chart with
+	// hourly data.
+	hourlyChartMaxRange = 90 * day
+)
+
+// ohlcDays are the values of days parameter accepted by /ohlc.
+var ohlcDays = []int{1, 7, 14, 30}
+
+// Candles returns the daily candles of given token in given currency.
+// Candles within last 30 days are aggregated from /ohlc candles, older
+// ones from hourly market chart prices. The volumes are the 24 hours
+// volumes of market chart at the end of each day.
+func (cg *CoinGecko) Candles(ctx context.Context, token, currency string, from, to time.Time) ([]tokenrate.Candle, error) {
+	from = from.UTC().Truncate(day)
+	to = to.UTC().Truncate(day)
+	if to.Before(from) {
+		return nil, errors.Errorf("from %s is after to %s", from, to)
+	}
+
+	var (
+		samples []tokenrate.Candle
+		err     error
+	)
+	if time.Since(from) <= ohlcMaxAge {
+		samples, err = cg.ohlc(ctx, token, currency, from)
+	} else {
+		samples, err = cg.hourlyPrices(ctx, token, currency, from, to.Add(day))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	chart, err := cg.marketChart(ctx, fmt.Sprintf(marketChartRangeEndpoint, cg.baseURL, token), currency, from, to.Add(day+time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	volumes := make([]tokenrate.RatePoint, 0, len(chart.TotalVolumes))
+	for _, v := range chart.TotalVolumes {
+		volumes = append(volumes, tokenrate.RatePoint{Timestamp: msToTime(v[0]), Rate: v[1]})
+	}
+
+	candles := aggregateDaily(samples, from, to)
+	for i := range candles {
+		if volume, ok := tokenrate.NearestPoint(volumes, candles[i].Timestamp.Add(day)); ok {
+			candles[i].Volume = volume.Rate
+		}
+	}
+	return candles, nil
+}
+
+// USDCandles returns the daily ETH/USD candles.
+func (cg *CoinGecko) USDCandles(ctx context.Context, from, to time.Time) ([]tokenrate.Candle, error) {
+	return cg.Candles(ctx, ethereumID, usdID, from, to)
+}
+
+// ohlc returns the /ohlc candles since from, each item of the response
+// is [time, open, high, low, close]. The timestamp of CoinGecko candles
+// is the end of the period, it is converted to the start.
+func (cg *CoinGecko) ohlc(ctx context.Context, token, currency string, from time.Time) ([]tokenrate.Candle, error) {
+	days := ohlcDays[len(ohlcDays)-1]
+	for _, d := range ohlcDays {
+		if time.Since(from) <= time.Duration(d)*day {
+			days = d
+			break
+		}
+	}
+	q := url.Values{}
+	q.Add("vs_currency", currency)
+	q.Add("days", strconv.Itoa(days))
+	var data [][5]float64
+	if err := cg.get(ctx, fmt.Sprintf(ohlcEndpoint, cg.baseURL, token), q, &data); err != nil {
+		return nil, err
+	}
+	var period time.Duration
+	if len(data) > 1 {
+		period = msToTime(data[1][0]).Sub(msToTime(data[0][0]))
+	}
+	candles := make([]tokenrate.Candle, 0, len(data))
+	for _, v := range data {
+		candles = append(candles, tokenrate.Candle{
+			Timestamp: msToTime(v[0]).Add(-period),
+			Open:      v[1],
+			High:      v[2],
+			Low:       v[3],
+			Close:     v[4],
+		})
+	}
+	return candles, nil
+}
+
+// hourlyPrices returns the hourly market chart prices between from and
+// to as single price candles, queried in chunks keeping hourly data.
+func (cg *CoinGecko) hourlyPrices(ctx context.Context, token, currency string, from, to time.Time) ([]tokenrate.Candle, error) {
+	var candles []tokenrate.Candle
+	for start := from; start.Before(to); start = start.Add(hourlyChartMaxRange) {
+		end := start.Add(hourlyChartMaxRange)
+		if end.After(to) {
+			end = to
+		}
+		points, err := cg.marketChartRange(ctx, fmt.Sprintf(marketChartRangeEndpoint, cg.baseURL, token), currency, start, end)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range points {
+			candles = append(candles, tokenrate.Candle{
+				Timestamp: p.Timestamp,
+				Open:      p.Rate,
+				High:      p.Rate,
+				Low:       p.Rate,
+				Close:     p.Rate,
+			})
+		}
+	}
+	return candles, nil
+}
+
+// aggregateDaily aggregates the intraday candles to daily candles of
+// the days from from to to. Days without data are omitted.
+func aggregateDaily(samples []tokenrate.Candle, from, to time.Time) []tokenrate.Candle {
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i].Timestamp.Before(samples[j].Timestamp)
+	})
+	var candles []tokenrate.Candle
+	for _, s := range samples {
+		d := s.Timestamp.UTC().Truncate(day)
+		if d.Before(from) || d.After(to) {
+			continue
+		}
+		n := len(candles)
+		if n == 0 || !candles[n-1].Timestamp.Equal(d) {
+			candles = append(candles, tokenrate.Candle{
+				Timestamp: d,
+				Open:      s.Open,
+				High:      s.High,
+				Low:       s.Low,
+				Close:     s.Close,
+			})
+			continue
+		}
+		c := &candles[n-1]
+		if s.High > c.High {
+			c.High = s.High
+		}
+		if s.Low < c.Low {
+			c.Low = s.Low
+		}
+		c.Close = s.Close
+	}
+	return candles
+}
@@ -1,39 +1,68 @@
 package coingecko
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/KyberNetwork/tokenrate"
+	"github.com/KyberNetwork/tokenrate/common"
+	"github.com/KyberNetwork/tokenrate/pkg/httpclient"
 )
 
 const providerName = "coingecko"
 
 const (
-	timeLayout         = "02-01-2006"
-	currentEndpoint    = "%s/coins/%s"
-	historicalEndpoint = "%s/coins/%s/history"
+	ethereumID = "ethereum"
+	usdID      = "usd"
+)
+
+const (
+	timeLayout          = "02-01-2006"
+	currentEndpoint     = "%s/coins/%s"
+	historicalEndpoint  = "%s/coins/%s/history"
+	simplePriceEndpoint = "%s/simple/price"
+)
+
+const (
+	defaultBaseURL = "https://api.coingecko.com/api/v3"
+	// proBaseURL is the base URL of the paid tier, used by default by
+	// NewPro.
+	proBaseURL   = "https://pro-api.coingecko.com/api/v3"
+	apiKeyHeader = "x-cg-pro-api-key"
 )
 
 // CoinGecko is the CoinGecko implementation of Provider. The
-// precision of CoinGecko provider is up to day.
+// precision of Rate is up to day, RateAt answers with hourly or 5
+// minutes precision.
 type CoinGecko struct {
 	client  *http.Client
 	baseURL string
+	apiKey  string
 }
 
-// New creates a new CoinGecko instance.
-func New() *CoinGecko {
-	const (
-		defaultTimeout = time.Second * 10
-		baseURL        = "https://api.coingecko.com/api/v3"
-	)
-	client := &http.Client{
-		Timeout: defaultTimeout,
+// New creates a new CoinGecko instance of the public API.
+func New(opts ...httpclient.Option) *CoinGecko {
+	cfg := httpclient.NewConfig(defaultBaseURL, opts...)
+	return &CoinGecko{
+		client:  cfg.Client,
+		baseURL: cfg.BaseURL,
 	}
+}
+
+// NewPro creates a new CoinGecko instance of the Pro API, given API key
+// is sent in every request.
+func NewPro(key string, opts ...httpclient.Option) *CoinGecko {
+	cfg := httpclient.NewConfig(proBaseURL, opts...)
 	return &CoinGecko{
-		client:  client,
-		baseURL: baseURL,
+		client:  cfg.Client,
+		baseURL: cfg.BaseURL,
+		apiKey:  key,
 	}
 }
 
@@ -47,56 +76,69 @@ type marketData struct {
 
 // Rate returns the rate of given token in real world currency at given timestamp.
 func (cg *CoinGecko) Rate(token, currency string, timestamp time.Time) (float64, error) {
-	var endpoint string
-	currentDate := time.Now().UTC().Format(timeLayout)
-	queryDate := timestamp.UTC().Format(timeLayout)
+	return cg.RateContext(context.Background(), token, currency, timestamp)
+}
 
-	if currentDate == queryDate {
+// RateContext is like Rate but the request is bound to given context.
+func (cg *CoinGecko) RateContext(ctx context.Context, token, currency string, timestamp time.Time) (float64, error) {
+	endpoint := historicalEndpoint
+	if common.IsToday(timestamp) {
 		endpoint = currentEndpoint
-	} else {
-		endpoint = historicalEndpoint
 	}
 
-	url := fmt.Sprintf(endpoint, cg.baseURL, token)
-	req, err := http.NewRequest(http.MethodGet, url, nil)
-	if err != nil {
-		return 0, err
-	}
-	req.Header.Add("Accept", "application/json")
-	q := req.URL.Query()
+	q := url.Values{}
 	q.Add("date", timestamp.UTC().Format(timeLayout))
-	req.URL.RawQuery = q.Encode()
-	rsp, err := cg.client.Do(req)
-	if err != nil {
-		return 0, err
-	}
-	defer rsp.Body.Close()
-
-	if rsp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("unexpected status code: %s", rsp.Status)
-	}
-
 	var history = &historyResponse{}
-	if err = json.NewDecoder(rsp.Body).Decode(history); err != nil {
+	if err := cg.get(ctx, fmt.Sprintf(endpoint, cg.baseURL, token), q, history); err != nil {
 		return 0, err
 	}
 	rate, ok := history.MarketData.CurrentPrice[currency]
 	if !ok {
-		return 0, fmt.Errorf("currency %q not found in market data", currency)
+		return 0, errors.Wrapf(tokenrate.ErrUnsupportedCurrency, "currency %q not found in market data", currency)
 	}
 	return rate, nil
 }
 
 // USDRate returns the historical price of ETH.
 func (cg *CoinGecko) USDRate(timestamp time.Time) (float64, error) {
-	const (
-		ethereumID = "ethereum"
-		usdID      = "usd"
-	)
-	return cg.Rate(ethereumID, usdID, timestamp)
+	return cg.USDRateContext(context.Background(), timestamp)
 }
 
-//Name return name of CoinGecko provider name
+// USDRateContext is like USDRate but the request is bound to given context.
+func (cg *CoinGecko) USDRateContext(ctx context.Context, timestamp time.Time) (float64, error) {
+	return cg.RateContext(ctx, ethereumID, usdID, timestamp)
+}
+
+// Name return name of CoinGecko provider name
 func (cg *CoinGecko) Name() string {
 	return providerName
 }
+
+// get sends a GET request to given endpoint and decodes the JSON
+// response to v.
+func (cg *CoinGecko) get(ctx context.Context, endpoint string, q url.Values, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Add("Accept", "application/json")
+	if len(cg.apiKey) != 0 {
+		req.Header.Add(apiKeyHeader, cg.apiKey)
+	}
+	req.URL.RawQuery = q.Encode()
+	rsp, err := cg.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+
+	switch rsp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return errors.Wrapf(tokenrate.ErrUnsupportedToken, "%s not found", req.URL.Path)
+	default:
+		return tokenrate.NewUpstreamError(rsp)
+	}
+	return json.NewDecoder(rsp.Body).Decode(v)
+}
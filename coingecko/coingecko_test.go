@@ -1,8 +1,19 @@
 package coingecko
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/tokenrate"
+	"github.com/KyberNetwork/tokenrate/pkg/httpclient"
 )
 
 const cgName = "coingecko"
@@ -31,3 +42,205 @@ func TestCoinGecko(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestCoinGeckoErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/coins/unknown":
+			w.WriteHeader(http.StatusNotFound)
+		case "/coins/limited":
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			_, _ = w.Write([]byte(`{"market_data":{"current_price":{"usd":100.0}}}`))
+		}
+	}))
+	defer ts.Close()
+
+	cg := New(httpclient.WithBaseURL(ts.URL))
+
+	rate, err := cg.Rate("ethereum", "usd", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 100.0, rate)
+
+	_, err = cg.Rate("ethereum", "sgd", time.Now())
+	assert.Equal(t, tokenrate.ErrUnsupportedCurrency, errors.Cause(err))
+
+	_, err = cg.Rate("unknown", "usd", time.Now())
+	assert.Equal(t, tokenrate.ErrUnsupportedToken, errors.Cause(err))
+
+	_, err = cg.Rate("limited", "usd", time.Now())
+	retryAfter, ok := tokenrate.RetryAfter(err)
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, retryAfter)
+}
+
+func TestCoinGeckoBatchRate(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/simple/price", r.URL.Path)
+		require.Equal(t, "ethereum,unknown", r.URL.Query().Get("ids"))
+		require.Equal(t, "usd,sgd", r.URL.Query().Get("vs_currencies"))
+		_, _ = w.Write([]byte(`{"ethereum":{"usd":100.0}}`))
+	}))
+	defer ts.Close()
+
+	cg := New(httpclient.WithBaseURL(ts.URL))
+
+	result, err := cg.BatchRate(context.Background(), []string{"ethereum", "unknown"}, []string{"usd", "sgd"}, time.Now())
+	require.NoError(t, err)
+	assert.NoError(t, result[0][0].Err)
+	assert.Equal(t, 100.0, result[0][0].Rate)
+	assert.Equal(t, tokenrate.ErrUnsupportedCurrency, errors.Cause(result[0][1].Err))
+	assert.Equal(t, tokenrate.ErrUnsupportedToken, errors.Cause(result[1][0].Err))
+	assert.Equal(t, tokenrate.ErrUnsupportedToken, errors.Cause(result[1][1].Err))
+}
+
+func TestCoinGeckoRateRange(t *testing.T) {
+	var (
+		from = time.Date(2019, 10, 1, 0, 0, 0, 0, time.UTC)
+		to   = time.Date(2019, 10, 3, 0, 0, 0, 0, time.UTC)
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/coins/ethereum/market_chart/range", r.URL.Path)
+		require.Equal(t, "usd", r.URL.Query().Get("vs_currency"))
+		require.Equal(t, "1569888000", r.URL.Query().Get("from"))
+		require.Equal(t, "1570060800", r.URL.Query().Get("to"))
+		_, _ = w.Write([]byte(`{"prices":[[1569888000000,180.5],[1569974400000,181.5],[1570060800000,182.5]]}`))
+	}))
+	defer ts.Close()
+
+	cg := New(httpclient.WithBaseURL(ts.URL))
+
+	points, err := cg.USDRateRange(context.Background(), from, to)
+	require.NoError(t, err)
+	require.Len(t, points, 3)
+	assert.True(t, points[0].Timestamp.Equal(from))
+	assert.Equal(t, 180.5, points[0].Rate)
+	assert.True(t, points[2].Timestamp.Equal(to))
+	assert.Equal(t, 182.5, points[2].Rate)
+}
+
+func TestCoinGeckoRateByContract(t *testing.T) {
+	const address = "0xdd974d5c2e2928dea5f71b9825b8b646686bd200"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/coins/ethereum/contract/" + address:
+			_, _ = w.Write([]byte(`{"market_data":{"current_price":{"usd":0.25}}}`))
+		case "/coins/ethereum/contract/" + address + "/market_chart/range":
+			require.Equal(t, "usd", r.URL.Query().Get("vs_currency"))
+			_, _ = w.Write([]byte(`{"prices":[[1569884400000,0.19],[1569888000000,0.2],[1569891600000,0.21]]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	cg := New(httpclient.WithBaseURL(ts.URL))
+
+	rate, err := cg.RateByContract(context.Background(), "ethereum",
+		"0xdd974D5C2e2928deA5F71b9825b8b646686BD200", "usd", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 0.25, rate)
+
+	rate, err = cg.RateByContract(context.Background(), "ethereum", address, "usd",
+		time.Date(2019, 10, 1, 0, 10, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, 0.2, rate)
+
+	_, err = cg.RateByContract(context.Background(), "ethereum", "0x0", "usd", time.Now())
+	assert.Equal(t, tokenrate.ErrUnsupportedToken, errors.Cause(err))
+}
+
+func TestCoinGeckoRateAt(t *testing.T) {
+	// 2019-10-01 23:50 UTC
+	timestamp := time.Date(2019, 10, 1, 23, 50, 0, 0, time.UTC)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/coins/ethereum/market_chart/range":
+			_, _ = w.Write([]byte(`{"prices":[[1569973200000,180.0],[1569973500000,180.5],[1569973800000,181.0],[1569974100000,181.5]]}`))
+		case "/coins/bitcoin/market_chart/range":
+			_, _ = w.Write([]byte(`{"prices":[[1569973800000,8300.0]]}`))
+		case "/coins/ethereum/history":
+			require.Equal(t, "01-10-2019", r.URL.Query().Get("date"))
+			_, _ = w.Write([]byte(`{"market_data":{"current_price":{"usd":175.0}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	cg := New(httpclient.WithBaseURL(ts.URL))
+
+	sample, err := cg.RateAt(context.Background(), "ethereum", "usd", timestamp, tokenrate.FiveMinutes)
+	require.NoError(t, err)
+	assert.Equal(t, 181.0, sample.Rate)
+	assert.True(t, sample.Timestamp.Equal(time.Date(2019, 10, 1, 23, 50, 0, 0, time.UTC)))
+	assert.Equal(t, tokenrate.FiveMinutes, sample.Granularity)
+
+	// the granularity of a single sample is unknown
+	sample, err = cg.RateAt(context.Background(), "bitcoin", "usd", timestamp, tokenrate.FiveMinutes)
+	require.NoError(t, err)
+	assert.Equal(t, 8300.0, sample.Rate)
+	assert.Equal(t, tokenrate.Daily, sample.Granularity)
+
+	sample, err = cg.RateAt(context.Background(), "ethereum", "usd", timestamp, tokenrate.Daily)
+	require.NoError(t, err)
+	assert.Equal(t, 175.0, sample.Rate)
+	assert.True(t, sample.Timestamp.Equal(time.Date(2019, 10, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, tokenrate.Daily, sample.Granularity)
+}
+
+func TestCoinGeckoCandles(t *testing.T) {
+	var (
+		from  = time.Date(2019, 10, 1, 0, 0, 0, 0, time.UTC)
+		today = time.Now().UTC().Truncate(24 * time.Hour)
+		ms    = func(t time.Time) int64 { return t.UnixNano() / int64(time.Millisecond) }
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/coins/ethereum/market_chart/range":
+			_, _ = fmt.Fprintf(w, `{"prices":[[%d,180],[%d,185],[%d,178],[%d,182],[%d,190]],`+
+				`"total_volumes":[[%d,1000],[%d,2000],[%d,3000]]}`,
+				ms(from), ms(from.Add(time.Hour)), ms(from.Add(2*time.Hour)), ms(from.Add(23*time.Hour)),
+				ms(from.Add(24*time.Hour)),
+				ms(from), ms(from.Add(24*time.Hour)), ms(today.Add(24*time.Hour)))
+		case "/coins/ethereum/ohlc":
+			require.Equal(t, "1", r.URL.Query().Get("days"))
+			_, _ = fmt.Fprintf(w, `[[%d,200,210,195,205],[%d,205,215,200,201]]`,
+				ms(today.Add(4*time.Hour)), ms(today.Add(8*time.Hour)))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	cg := New(httpclient.WithBaseURL(ts.URL))
+
+	candles, err := cg.USDCandles(context.Background(), from, from)
+	require.NoError(t, err)
+	assert.Equal(t, []tokenrate.Candle{
+		{Timestamp: from, Open: 180, High: 185, Low: 178, Close: 182, Volume: 2000},
+	}, candles)
+
+	candles, err = cg.USDCandles(context.Background(), today, today)
+	require.NoError(t, err)
+	assert.Equal(t, []tokenrate.Candle{
+		{Timestamp: today, Open: 200, High: 215, Low: 195, Close: 201, Volume: 3000},
+	}, candles)
+}
+
+func TestCoinGeckoPro(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "secret", r.Header.Get("x-cg-pro-api-key"))
+		_, _ = w.Write([]byte(`{"market_data":{"current_price":{"usd":180.5}}}`))
+	}))
+	defer ts.Close()
+
+	cg := NewPro("secret", httpclient.WithBaseURL(ts.URL+"/"))
+	rate, err := cg.USDRate(time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 180.5, rate)
+
+	assert.Equal(t, proBaseURL, NewPro("secret").baseURL)
+	assert.Equal(t, defaultBaseURL, New().baseURL)
+}
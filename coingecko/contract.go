@@ -0,0 +1,57 @@
+package coingecko
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/KyberNetwork/tokenrate"
+	"github.com/KyberNetwork/tokenrate/common"
+)
+
+const (
+	contractEndpoint                 = "%s/coins/%s/contract/%s"
+	contractMarketChartRangeEndpoint = "%s/coins/%s/contract/%s/market_chart/range"
+)
+
+// contractSampleWindow is the time window around the queried timestamp
+// to look for the nearest price sample of historical contract queries.
+const contractSampleWindow = 12 * time.Hour
+
+// RateByContract returns the rate of the token deployed at given
+// address in real world currency at given timestamp. The chain is the
+// CoinGecko asset platform ID, e.g ethereum. Today price is the current
+// price, historical price is the market chart sample nearest to given
+// timestamp.
+func (cg *CoinGecko) RateByContract(ctx context.Context, chain, address, currency string, timestamp time.Time) (float64, error) {
+	address = strings.ToLower(address)
+	if common.IsToday(timestamp) {
+		var coin = &historyResponse{}
+		if err := cg.get(ctx, fmt.Sprintf(contractEndpoint, cg.baseURL, chain, address), url.Values{}, coin); err != nil {
+			return 0, err
+		}
+		rate, ok := coin.MarketData.CurrentPrice[currency]
+		if !ok {
+			return 0, errors.Wrapf(tokenrate.ErrUnsupportedCurrency, "currency %q not found in market data", currency)
+		}
+		return rate, nil
+	}
+
+	points, err := cg.marketChartRange(ctx,
+		fmt.Sprintf(contractMarketChartRangeEndpoint, cg.baseURL, chain, address),
+		currency,
+		timestamp.Add(-contractSampleWindow),
+		timestamp.Add(contractSampleWindow))
+	if err != nil {
+		return 0, err
+	}
+	point, ok := tokenrate.NearestPoint(points, timestamp)
+	if !ok {
+		return 0, errors.Wrapf(tokenrate.ErrUnsupportedTimestamp, "no price of contract %s at %s", address, timestamp)
+	}
+	return point.Rate, nil
+}
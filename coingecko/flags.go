@@ -2,9 +2,32 @@ package coingecko
 
 import (
 	"github.com/urfave/cli"
+
+	"github.com/KyberNetwork/tokenrate/common"
+	"github.com/KyberNetwork/tokenrate/pkg/httpclient"
+)
+
+const (
+	apiKeyFlag = "coingecko-api-key"
 )
 
+// NewFlags return cli config for coingecko
+func NewFlags() []cli.Flag {
+	// the base URL defaults to the public or Pro API depending on API key
+	return append([]cli.Flag{
+		cli.StringFlag{
+			Name:   apiKeyFlag,
+			Usage:  "CoinGecko Pro API Key",
+			EnvVar: "COINGECKO_API_KEY",
+		},
+	}, httpclient.NewFlags(common.Coingecko, "")...)
+}
+
 // NewCoinGeckoFromContext return coingecko provider
 func NewCoinGeckoFromContext(c *cli.Context) *CoinGecko {
-	return New()
+	opts := httpclient.NewOptionsFromContext(c, common.Coingecko)
+	if key := c.String(apiKeyFlag); len(key) != 0 {
+		return NewPro(key, opts...)
+	}
+	return New(opts...)
 }
@@ -0,0 +1,66 @@
+package coingecko
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/KyberNetwork/tokenrate"
+)
+
+// sampleWindows are the half widths of market chart range queried
+// around the timestamp for each granularity. CoinGecko decides the
+// granularity from the range duration: 5 minutes within 1 day, hourly
+// within 90 days.
+var sampleWindows = map[tokenrate.Granularity]time.Duration{
+	tokenrate.FiveMinutes: 30 * time.Minute,
+	tokenrate.Hourly:      24 * time.Hour,
+}
+
+// RateAt returns the sample of given token in given currency nearest to
+// timestamp. Hourly and 5 minutes samples come from market chart data,
+// CoinGecko answers with coarser data for old timestamps. Daily
+// samples are the prices at 00:00 UTC like Rate.
+func (cg *CoinGecko) RateAt(ctx context.Context, token, currency string, timestamp time.Time, granularity tokenrate.Granularity) (tokenrate.Sample, error) {
+	window, ok := sampleWindows[granularity]
+	if !ok {
+		if granularity < tokenrate.Daily {
+			return tokenrate.Sample{}, errors.Wrapf(tokenrate.ErrUnsupportedTimestamp, "granularity %s is not supported", granularity)
+		}
+		rate, err := cg.RateContext(ctx, token, currency, timestamp)
+		if err != nil {
+			return tokenrate.Sample{}, err
+		}
+		return tokenrate.Sample{
+			Rate:        rate,
+			Timestamp:   timestamp.UTC().Truncate(time.Duration(tokenrate.Daily)),
+			Granularity: tokenrate.Daily,
+		}, nil
+	}
+
+	points, err := cg.marketChartRange(ctx,
+		fmt.Sprintf(marketChartRangeEndpoint, cg.baseURL, token),
+		currency,
+		timestamp.Add(-window),
+		timestamp.Add(window))
+	if err != nil {
+		return tokenrate.Sample{}, err
+	}
+	point, ok := tokenrate.NearestPoint(points, timestamp)
+	if !ok {
+		return tokenrate.Sample{}, errors.Wrapf(tokenrate.ErrUnsupportedTimestamp, "no price of %s at %s", token, timestamp)
+	}
+	actual, ok := tokenrate.GuessGranularity(points)
+	if !ok {
+		// a single sample tells nothing about the interval of the data,
+		// only the daily granularity is safe to assume
+		actual = tokenrate.Daily
+	}
+	return tokenrate.Sample{
+		Rate:        point.Rate,
+		Timestamp:   point.Timestamp,
+		Granularity: actual,
+	}, nil
+}
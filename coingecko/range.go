@@ -0,0 +1,68 @@
+package coingecko
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/KyberNetwork/tokenrate"
+)
+
+const marketChartRangeEndpoint = "%s/coins/%s/market_chart/range"
+
+// marketChartResponse is the response of market_chart endpoints, each
+// item is a pair of unix timestamp in milliseconds and value.
+type marketChartResponse struct {
+	Prices       [][2]float64 `json:"prices"`
+	TotalVolumes [][2]float64 `json:"total_volumes"`
+}
+
+// RateRange returns the rates of given token in real world currency
+// between from and to in a single request. The data granularity is
+// decided by CoinGecko: 5 minutes for range within 1 day, hourly for
+// range within 90 days and daily above that.
+func (cg *CoinGecko) RateRange(ctx context.Context, token, currency string, from, to time.Time) ([]tokenrate.RatePoint, error) {
+	return cg.marketChartRange(ctx, fmt.Sprintf(marketChartRangeEndpoint, cg.baseURL, token), currency, from, to)
+}
+
+// USDRateRange returns the ETH/USD rates between from and to.
+func (cg *CoinGecko) USDRateRange(ctx context.Context, from, to time.Time) ([]tokenrate.RatePoint, error) {
+	return cg.RateRange(ctx, ethereumID, usdID, from, to)
+}
+
+func (cg *CoinGecko) marketChartRange(ctx context.Context, endpoint, currency string, from, to time.Time) ([]tokenrate.RatePoint, error) {
+	chart, err := cg.marketChart(ctx, endpoint, currency, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return toRatePoints(chart.Prices), nil
+}
+
+func (cg *CoinGecko) marketChart(ctx context.Context, endpoint, currency string, from, to time.Time) (*marketChartResponse, error) {
+	q := url.Values{}
+	q.Add("vs_currency", currency)
+	q.Add("from", strconv.FormatInt(from.Unix(), 10))
+	q.Add("to", strconv.FormatInt(to.Unix(), 10))
+	var chart = &marketChartResponse{}
+	if err := cg.get(ctx, endpoint, q, chart); err != nil {
+		return nil, err
+	}
+	return chart, nil
+}
+
+func toRatePoints(values [][2]float64) []tokenrate.RatePoint {
+	points := make([]tokenrate.RatePoint, 0, len(values))
+	for _, v := range values {
+		points = append(points, tokenrate.RatePoint{
+			Timestamp: msToTime(v[0]),
+			Rate:      v[1],
+		})
+	}
+	return points
+}
+
+func msToTime(ms float64) time.Time {
+	return time.Unix(0, int64(ms)*int64(time.Millisecond)).UTC()
+}
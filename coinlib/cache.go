@@ -0,0 +1,76 @@
+package coinlib
+
+import (
+	"sync"
+	"time"
+)
+
+// cacheKey is the key of cached rates, a pair of symbol and preference
+// currency.
+type cacheKey struct {
+	symbol string
+	pref   string
+}
+
+type cachedRate struct {
+	value   float64
+	expires time.Time
+}
+
+// CacheStats is the statistics of CoinLib response cache.
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// rateCache is a concurrency safe cache of rates, the entries expire
+// after ttl.
+type rateCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[cacheKey]cachedRate
+	stats   CacheStats
+}
+
+func newRateCache(ttl time.Duration) *rateCache {
+	return &rateCache{
+		ttl:     ttl,
+		entries: make(map[cacheKey]cachedRate),
+	}
+}
+
+func (c *rateCache) get(key cacheKey) (float64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if ok && time.Now().Before(entry.expires) {
+		c.stats.Hits++
+		return entry.value, true
+	}
+	if ok {
+		delete(c.entries, key)
+	}
+	c.stats.Misses++
+	return 0, false
+}
+
+// contains returns true if there is an unexpired entry of key, it is
+// not counted in the statistics.
+func (c *rateCache) contains(key cacheKey) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	return ok && time.Now().Before(entry.expires)
+}
+
+func (c *rateCache) set(key cacheKey, value float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = cachedRate{value: value, expires: time.Now().Add(c.ttl)}
+}
+
+func (c *rateCache) statistics() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
@@ -1,25 +1,38 @@
 package coinlib
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
 
+	"github.com/KyberNetwork/tokenrate"
 	"github.com/KyberNetwork/tokenrate/common"
 )
 
-// CoinLib data source only support
+const (
+	baseURL      = "https://coinlib.io/api/v1"
+	coinEndpoint = "%s/coin"
+)
+
+// CoinLib is the CoinLib implementation of Provider. CoinLib data
+// source only support query today price. The responses are cached and
+// shared across goroutines to stay within the API quota.
 type CoinLib struct {
-	c               *http.Client
-	key             string
-	cachedValue     float64
-	cachedTime      time.Time
-	cachedTimeValid time.Duration
+	c       *http.Client
+	key     string
+	baseURL string
+	cache   *rateCache
+
+	mu         sync.Mutex
+	quota      tokenrate.Quota
+	quotaKnown bool
 }
 
 type priceResponse struct {
@@ -31,58 +44,100 @@ type priceResponse struct {
 	// there's other fields but we dont interested in them.
 }
 
-// USDRate ..
-func (c CoinLib) USDRate(timestamp time.Time) (float64, error) {
-	today := common.TimeOfTodayStart()
-	if timestamp != today {
-		return 0, fmt.Errorf("coinlib only support query today price")
+// Rate returns today rate of given token symbol, e.g ETH, in given
+// preference currency, e.g USD.
+func (c *CoinLib) Rate(token, currency string, timestamp time.Time) (float64, error) {
+	return c.RateContext(context.Background(), token, currency, timestamp)
+}
+
+// RateContext is like Rate but the request is bound to given context.
+func (c *CoinLib) RateContext(ctx context.Context, token, currency string, timestamp time.Time) (float64, error) {
+	if !common.IsToday(timestamp) {
+		return 0, errors.Wrap(tokenrate.ErrUnsupportedTimestamp, "coinlib only support query today price")
 	}
-	now := time.Now()
-	if now.Sub(c.cachedTime) < c.cachedTimeValid {
-		return c.cachedValue, nil
+	key := cacheKey{symbol: token, pref: currency}
+	if rate, ok := c.cache.get(key); ok {
+		return rate, nil
 	}
 	q := url.Values{}
 	q.Add("key", c.key)
-	q.Add("pref", "USD")
-	q.Add("symbol", "ETH") //https://coinlib.io/api/v1/coin?key=c28757f4&pref=USD&symbol=ETH
-	req, err := http.NewRequest(http.MethodGet, "https://coinlib.io/api/v1/coin?"+q.Encode(), nil)
+	q.Add("pref", currency)
+	q.Add("symbol", token) //https://coinlib.io/api/v1/coin?key=c28757f4&pref=USD&symbol=ETH
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(coinEndpoint, c.baseURL)+"?"+q.Encode(), nil)
 	if err != nil {
 		return 0, errors.Wrap(err, "make request to coinlib")
 	}
+	req = req.WithContext(ctx)
 	resp, err := c.c.Do(req)
 	if err != nil {
 		return 0, errors.Wrap(err, "query to coinlib")
 	}
+	defer resp.Body.Close()
 
 	data, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return 0, errors.Wrap(err, "read coinlib response")
 	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, errors.Wrap(tokenrate.NewUpstreamError(resp), string(data))
+	}
 	var pr priceResponse
 	if err = json.Unmarshal(data, &pr); err != nil {
 		return 0, errors.Wrap(err, "unmarshal coinlib data")
 	}
-	if resp.StatusCode != http.StatusOK {
-		return 0, errors.Wrap(fmt.Errorf("unexpected response code %d", resp.StatusCode), string(data))
-	}
-	c.cachedTime = time.Now()
-	c.cachedValue = pr.Price
+	c.setRemaining(pr.Remaining)
+	c.cache.set(key, pr.Price)
 	return pr.Price, nil
 }
 
+// USDRate ..
+func (c *CoinLib) USDRate(timestamp time.Time) (float64, error) {
+	return c.USDRateContext(context.Background(), timestamp)
+}
+
+// USDRateContext is like USDRate but the request is bound to given context.
+func (c *CoinLib) USDRateContext(ctx context.Context, timestamp time.Time) (float64, error) {
+	return c.RateContext(ctx, common.ETHID, common.USDID, timestamp)
+}
+
+// Cached returns true if the rate is answered from the response cache
+// without a request to CoinLib.
+func (c *CoinLib) Cached(token, currency string, timestamp time.Time) bool {
+	return common.IsToday(timestamp) && c.cache.contains(cacheKey{symbol: token, pref: currency})
+}
+
 // Name ...
-func (c CoinLib) Name() string {
+func (c *CoinLib) Name() string {
 	return common.CoinLib
 }
 
+// Quota returns the remaining requests of current hour as reported by
+// the last CoinLib response.
+func (c *CoinLib) Quota() (tokenrate.Quota, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.quota, c.quotaKnown
+}
+
+func (c *CoinLib) setRemaining(remaining int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.quota = tokenrate.Quota{Remaining: remaining}
+	c.quotaKnown = true
+}
+
+// CacheStats returns the hit and miss counts of the response cache.
+func (c *CoinLib) CacheStats() CacheStats {
+	return c.cache.statistics()
+}
+
 // New make new coinlib client
 func New(key string) *CoinLib {
 	return &CoinLib{
-		c:               &http.Client{},
-		key:             key,
-		cachedValue:     0,
-		cachedTime:      time.Time{},
-		cachedTimeValid: time.Minute * 5, // coinlib rate limit is 180/hour, we can query them for every 3 mins,
+		c:       &http.Client{},
+		key:     key,
+		baseURL: baseURL,
+		cache:   newRateCache(time.Minute * 5), // coinlib rate limit is 180/hour, we can query them for every 3 mins,
 		// but let's use 5 for now and see.
 	}
 }
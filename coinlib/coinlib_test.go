@@ -0,0 +1,93 @@
+package coinlib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/tokenrate"
+	"github.com/KyberNetwork/tokenrate/common"
+)
+
+func TestCoinLibCache(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		require.Equal(t, "/coin", r.URL.Path)
+		require.Equal(t, "secret", r.URL.Query().Get("key"))
+		switch r.URL.Query().Get("symbol") {
+		case "ETH":
+			_, _ = w.Write([]byte(`{"symbol":"ETH","price":180.5,"remaining":179}`))
+		default:
+			_, _ = w.Write([]byte(`{"symbol":"KNC","price":0.25,"remaining":178}`))
+		}
+	}))
+	defer ts.Close()
+
+	c := New("secret")
+	c.baseURL = ts.URL
+
+	assert.False(t, c.Cached(common.ETHID, common.USDID, common.TimeOfTodayStart()))
+	rate, err := c.USDRate(common.TimeOfTodayStart())
+	require.NoError(t, err)
+	assert.Equal(t, 180.5, rate)
+	assert.True(t, c.Cached(common.ETHID, common.USDID, common.TimeOfTodayStart()))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rate, err := c.USDRate(common.TimeOfTodayStart())
+			assert.NoError(t, err)
+			assert.Equal(t, 180.5, rate)
+		}()
+	}
+	wg.Wait()
+
+	rate, err = c.Rate("KNC", common.USDID, common.TimeOfTodayStart())
+	require.NoError(t, err)
+	assert.Equal(t, 0.25, rate)
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	assert.Equal(t, CacheStats{Hits: 10, Misses: 2}, c.CacheStats())
+}
+
+func TestCoinLibConcurrentRate(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"symbol":"` + r.URL.Query().Get("symbol") + `","price":1.5,"remaining":100}`))
+	}))
+	defer ts.Close()
+
+	c := New("secret")
+	c.baseURL = ts.URL
+
+	var (
+		wg      sync.WaitGroup
+		symbols = []string{"ETH", "KNC", "BTC", "DAI"}
+	)
+	for i := 0; i < 40; i++ {
+		wg.Add(1)
+		go func(symbol string) {
+			defer wg.Done()
+			// any time of today is accepted
+			rate, err := c.Rate(symbol, common.USDID, time.Now())
+			assert.NoError(t, err)
+			assert.Equal(t, 1.5, rate)
+			_, _ = c.Quota()
+		}(symbols[i%len(symbols)])
+	}
+	wg.Wait()
+	stats := c.CacheStats()
+	assert.Equal(t, uint64(40), stats.Hits+stats.Misses)
+
+	_, err := c.Rate(common.ETHID, common.USDID, time.Now().Add(-48*time.Hour))
+	assert.Equal(t, tokenrate.ErrUnsupportedTimestamp, errors.Cause(err))
+}
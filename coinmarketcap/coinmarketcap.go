@@ -0,0 +1,295 @@
+package coinmarketcap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/KyberNetwork/tokenrate"
+	"github.com/KyberNetwork/tokenrate/common"
+	"github.com/KyberNetwork/tokenrate/pkg/httpclient"
+)
+
+const (
+	defaultBaseURL     = "https://pro-api.coinmarketcap.com"
+	latestEndpoint     = "%s/v1/cryptocurrency/quotes/latest"
+	historicalEndpoint = "%s/v1/cryptocurrency/quotes/historical"
+	keyInfoEndpoint    = "%s/v1/key/info"
+	apiKeyHeader       = "X-CMC_PRO_API_KEY"
+	// quotaRetryInterval is the interval between queries to the key
+	// info endpoint while it fails, e.g. not allowed for the plan.
+	quotaRetryInterval = 10 * time.Minute
+	day                = 24 * time.Hour
+)
+
+// error codes of CoinMarketCap API, see
+// https://coinmarketcap.com/api/documentation/v1/#section/Errors-and-Rate-Limits
+const (
+	planNotAuthorizedCode = 1006
+	minuteRateLimitCode   = 1008
+	dailyRateLimitCode    = 1009
+	monthlyRateLimitCode  = 1010
+)
+
+// CoinMarketCap is the CoinMarketCap implementation of Provider. Tokens
+// and currencies are CoinMarketCap symbols, e.g. ETH, KNC, USD. Today
+// rate is the latest quote, historical rates are the daily quote of
+// the day which requires a paid plan.
+//
+// CoinMarketCap has no credit usage headers, the credits a call costs
+// are only reported in status.credit_count of the response body. The
+// quota starts from the credits left reported by the key info endpoint
+// and each reported credit count is deducted from it.
+type CoinMarketCap struct {
+	client  *http.Client
+	key     string
+	baseURL string
+
+	mu         sync.Mutex
+	quota      tokenrate.Quota
+	quotaKnown bool
+	// quotaRetryAt is the time to query the key info endpoint again
+	// after a failure.
+	quotaRetryAt time.Time
+}
+
+// New creates a new CoinMarketCap instance with given API key.
+func New(key string, opts ...httpclient.Option) *CoinMarketCap {
+	cfg := httpclient.NewConfig(defaultBaseURL, opts...)
+	return &CoinMarketCap{
+		client:  cfg.Client,
+		key:     key,
+		baseURL: cfg.BaseURL,
+	}
+}
+
+type status struct {
+	ErrorCode    int    `json:"error_code"`
+	ErrorMessage string `json:"error_message"`
+	CreditCount  int    `json:"credit_count"`
+}
+
+type quote struct {
+	Price float64 `json:"price"`
+}
+
+type latestResponse struct {
+	Status status `json:"status"`
+	Data   map[string]struct {
+		Quote map[string]quote `json:"quote"`
+	} `json:"data"`
+}
+
+type historicalResponse struct {
+	Status status `json:"status"`
+	Data   struct {
+		Quotes []struct {
+			Timestamp time.Time        `json:"timestamp"`
+			Quote     map[string]quote `json:"quote"`
+		} `json:"quotes"`
+	} `json:"data"`
+}
+
+type keyInfoResponse struct {
+	Status status `json:"status"`
+	Data   struct {
+		Usage struct {
+			CurrentMonth struct {
+				CreditsLeft int `json:"credits_left"`
+			} `json:"current_month"`
+		} `json:"usage"`
+	} `json:"data"`
+}
+
+// Rate returns the rate of given token in given currency at given timestamp.
+func (cmc *CoinMarketCap) Rate(token, currency string, timestamp time.Time) (float64, error) {
+	return cmc.RateContext(context.Background(), token, currency, timestamp)
+}
+
+// RateContext is like Rate but the request is bound to given context.
+func (cmc *CoinMarketCap) RateContext(ctx context.Context, token, currency string, timestamp time.Time) (float64, error) {
+	token, currency = strings.ToUpper(token), strings.ToUpper(currency)
+	q := url.Values{}
+	q.Add("symbol", token)
+	q.Add("convert", currency)
+
+	if common.IsToday(timestamp) {
+		var latest latestResponse
+		if err := cmc.get(ctx, fmt.Sprintf(latestEndpoint, cmc.baseURL), q, &latest, &latest.Status); err != nil {
+			return 0, err
+		}
+		price, ok := latest.Data[token].Quote[currency]
+		if !ok {
+			return 0, errors.Wrapf(tokenrate.ErrUnsupportedCurrency, "no %s quote of %s", currency, token)
+		}
+		return price.Price, nil
+	}
+
+	start := timestamp.UTC().Truncate(day)
+	q.Add("time_start", start.Format(time.RFC3339))
+	q.Add("time_end", start.Add(day).Format(time.RFC3339))
+	q.Add("interval", "daily")
+	q.Add("count", "1")
+	var historical historicalResponse
+	if err := cmc.get(ctx, fmt.Sprintf(historicalEndpoint, cmc.baseURL), q, &historical, &historical.Status); err != nil {
+		return 0, err
+	}
+	if len(historical.Data.Quotes) == 0 {
+		return 0, errors.Wrapf(tokenrate.ErrUnsupportedTimestamp, "no quote of %s at %s", token, common.TimeToDateString(start))
+	}
+	price, ok := historical.Data.Quotes[0].Quote[currency]
+	if !ok {
+		return 0, errors.Wrapf(tokenrate.ErrUnsupportedCurrency, "no %s quote of %s", currency, token)
+	}
+	return price.Price, nil
+}
+
+// USDRate returns the historical price of ETH.
+func (cmc *CoinMarketCap) USDRate(timestamp time.Time) (float64, error) {
+	return cmc.USDRateContext(context.Background(), timestamp)
+}
+
+// USDRateContext is like USDRate but the request is bound to given context.
+func (cmc *CoinMarketCap) USDRateContext(ctx context.Context, timestamp time.Time) (float64, error) {
+	return cmc.RateContext(ctx, common.ETHID, common.USDID, timestamp)
+}
+
+// Name returns common.CoinMarketCap.
+func (cmc *CoinMarketCap) Name() string {
+	return common.CoinMarketCap
+}
+
+// Quota returns the API credits left in current month. It is fetched
+// from the key info endpoint with the first query, then the credit
+// count in the body of each response is deducted. If the key info
+// endpoint fails, it is queried again after 10 minutes.
+func (cmc *CoinMarketCap) Quota() (tokenrate.Quota, bool) {
+	cmc.mu.Lock()
+	defer cmc.mu.Unlock()
+	return cmc.quota, cmc.quotaKnown
+}
+
+// get sends a GET request to given endpoint and decodes the JSON
+// response to v, st is the status of v.
+func (cmc *CoinMarketCap) get(ctx context.Context, endpoint string, q url.Values, v interface{}, st *status) error {
+	if cmc.shouldRefreshQuota() {
+		// the key info endpoint does not cost credits, failure only
+		// leaves the quota unknown until the next attempt
+		if err := cmc.refreshQuota(ctx); err != nil {
+			cmc.mu.Lock()
+			cmc.quotaRetryAt = time.Now().Add(quotaRetryInterval)
+			cmc.mu.Unlock()
+		}
+	}
+	if err := cmc.do(ctx, endpoint, q, v); err != nil {
+		return err
+	}
+	cmc.useCredits(st.CreditCount)
+	return nil
+}
+
+// shouldRefreshQuota reports whether the quota is unknown and the key
+// info endpoint is not backing off after a failure.
+func (cmc *CoinMarketCap) shouldRefreshQuota() bool {
+	cmc.mu.Lock()
+	defer cmc.mu.Unlock()
+	return !cmc.quotaKnown && !time.Now().Before(cmc.quotaRetryAt)
+}
+
+func (cmc *CoinMarketCap) refreshQuota(ctx context.Context) error {
+	var info keyInfoResponse
+	if err := cmc.do(ctx, fmt.Sprintf(keyInfoEndpoint, cmc.baseURL), nil, &info); err != nil {
+		return err
+	}
+	cmc.mu.Lock()
+	defer cmc.mu.Unlock()
+	cmc.quota = tokenrate.Quota{
+		Remaining: info.Data.Usage.CurrentMonth.CreditsLeft,
+		Reset:     nextMonth(time.Now()),
+	}
+	cmc.quotaKnown = true
+	return nil
+}
+
+func (cmc *CoinMarketCap) useCredits(credits int) {
+	cmc.mu.Lock()
+	defer cmc.mu.Unlock()
+	if !cmc.quotaKnown {
+		return
+	}
+	if now := time.Now(); !now.Before(cmc.quota.Reset) {
+		// the known quota is of last month, wait for next refresh
+		cmc.quotaKnown = false
+		return
+	}
+	cmc.quota.Remaining -= credits
+}
+
+func (cmc *CoinMarketCap) do(ctx context.Context, endpoint string, q url.Values, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Add("Accept", "application/json")
+	req.Header.Add(apiKeyHeader, cmc.key)
+	req.URL.RawQuery = q.Encode()
+	rsp, err := cmc.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+
+	data, err := ioutil.ReadAll(rsp.Body)
+	if err != nil {
+		return errors.Wrap(err, "read coinmarketcap response")
+	}
+	if rsp.StatusCode != http.StatusOK {
+		var errRsp struct {
+			Status status `json:"status"`
+		}
+		_ = json.Unmarshal(data, &errRsp)
+		return apiError(rsp, &errRsp.Status)
+	}
+	return json.Unmarshal(data, v)
+}
+
+// apiError returns the error of a non 200 response with given status.
+func apiError(rsp *http.Response, st *status) error {
+	now := time.Now().UTC()
+	switch st.ErrorCode {
+	case minuteRateLimitCode:
+		return errors.Wrap(&tokenrate.ErrRateLimited{RetryAfter: now.Truncate(time.Minute).Add(time.Minute).Sub(now)}, st.ErrorMessage)
+	case dailyRateLimitCode:
+		return errors.Wrap(&tokenrate.ErrRateLimited{RetryAfter: now.Truncate(day).Add(day).Sub(now)}, st.ErrorMessage)
+	case monthlyRateLimitCode:
+		return errors.Wrap(&tokenrate.ErrRateLimited{RetryAfter: nextMonth(now).Sub(now)}, st.ErrorMessage)
+	case planNotAuthorizedCode:
+		return errors.Wrap(tokenrate.ErrUnsupportedTimestamp, st.ErrorMessage)
+	}
+	if rsp.StatusCode == http.StatusBadRequest && strings.Contains(st.ErrorMessage, "symbol") {
+		return errors.Wrap(tokenrate.ErrUnsupportedToken, st.ErrorMessage)
+	}
+	if rsp.StatusCode == http.StatusBadRequest && strings.Contains(st.ErrorMessage, "convert") {
+		return errors.Wrap(tokenrate.ErrUnsupportedCurrency, st.ErrorMessage)
+	}
+	if len(st.ErrorMessage) != 0 {
+		return errors.Wrap(tokenrate.NewUpstreamError(rsp), st.ErrorMessage)
+	}
+	return tokenrate.NewUpstreamError(rsp)
+}
+
+// nextMonth returns the start of the next UTC month of t, when the API
+// credits are reset.
+func nextMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+}
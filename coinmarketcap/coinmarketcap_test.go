@@ -0,0 +1,136 @@
+package coinmarketcap
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/tokenrate"
+	"github.com/KyberNetwork/tokenrate/pkg/httpclient"
+)
+
+func TestCoinMarketCap(t *testing.T) {
+	var keyInfoRequests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "secret", r.Header.Get("X-CMC_PRO_API_KEY"))
+		q := r.URL.Query()
+		switch r.URL.Path {
+		case "/v1/key/info":
+			keyInfoRequests++
+			_, _ = w.Write([]byte(`{"status":{"error_code":0,"credit_count":0},` +
+				`"data":{"usage":{"current_month":{"credits_used":100,"credits_left":9900}}}}`))
+		case "/v1/cryptocurrency/quotes/latest":
+			switch q.Get("symbol") {
+			case "ETH":
+				require.Equal(t, "USD", q.Get("convert"))
+				_, _ = w.Write([]byte(`{"status":{"error_code":0,"credit_count":1},` +
+					`"data":{"ETH":{"id":1027,"symbol":"ETH","quote":{"USD":{"price":200.5}}}}}`))
+			case "KNC":
+				w.WriteHeader(http.StatusTooManyRequests)
+				_, _ = w.Write([]byte(`{"status":{"error_code":1008,"error_message":"You've exceeded your API Key's HTTP request rate limit."}}`))
+			default:
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"status":{"error_code":400,"error_message":"Invalid value for \"symbol\": \"XYZ\""}}`))
+			}
+		case "/v1/cryptocurrency/quotes/historical":
+			if q.Get("symbol") != "ETH" {
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte(`{"status":{"error_code":1006,"error_message":"Your API Key subscription plan doesn't support this endpoint."}}`))
+				return
+			}
+			require.Equal(t, "2019-10-01T00:00:00Z", q.Get("time_start"))
+			require.Equal(t, "daily", q.Get("interval"))
+			_, _ = w.Write([]byte(`{"status":{"error_code":0,"credit_count":2},"data":{"id":1027,"symbol":"ETH",` +
+				`"quotes":[{"timestamp":"2019-10-01T23:59:00Z","quote":{"USD":{"price":180.5}}}]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	cmc := New("secret", httpclient.WithBaseURL(ts.URL))
+
+	rate, err := cmc.USDRate(time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 200.5, rate)
+
+	rate, err = cmc.RateContext(context.Background(), "eth", "usd", time.Date(2019, 10, 1, 10, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, 180.5, rate)
+
+	quota, ok := cmc.Quota()
+	require.True(t, ok)
+	assert.Equal(t, 9897, quota.Remaining)
+	assert.Equal(t, 1, keyInfoRequests)
+
+	_, err = cmc.Rate("XYZ", "USD", time.Now())
+	assert.Equal(t, tokenrate.ErrUnsupportedToken, errors.Cause(err))
+
+	_, err = cmc.Rate("KNC", "USD", time.Now())
+	retryAfter, ok := tokenrate.RetryAfter(err)
+	require.True(t, ok)
+	assert.True(t, retryAfter <= time.Minute)
+
+	_, err = cmc.Rate("KNC", "USD", time.Date(2019, 10, 1, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, tokenrate.ErrUnsupportedTimestamp, errors.Cause(err))
+}
+
+func TestCoinMarketCapQuotaBackoff(t *testing.T) {
+	var keyInfoRequests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/key/info":
+			keyInfoRequests++
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"status":{"error_code":1006,"error_message":"Your API Key subscription plan doesn't support this endpoint."}}`))
+		default:
+			_, _ = w.Write([]byte(`{"status":{"error_code":0,"credit_count":1},` +
+				`"data":{"ETH":{"id":1027,"symbol":"ETH","quote":{"USD":{"price":200.5}}}}}`))
+		}
+	}))
+	defer ts.Close()
+
+	cmc := New("secret", httpclient.WithBaseURL(ts.URL))
+	for i := 0; i < 3; i++ {
+		rate, err := cmc.USDRate(time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, 200.5, rate)
+	}
+	assert.Equal(t, 1, keyInfoRequests)
+	_, ok := cmc.Quota()
+	assert.False(t, ok)
+
+	// the key info endpoint is queried again after the back off
+	cmc.quotaRetryAt = time.Now()
+	_, err := cmc.USDRate(time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 2, keyInfoRequests)
+}
+
+func TestAPIError(t *testing.T) {
+	var (
+		now       = time.Now().UTC()
+		forbidden = &http.Response{StatusCode: http.StatusForbidden}
+		tooMany   = &http.Response{StatusCode: http.StatusTooManyRequests}
+	)
+	for code, maxWait := range map[int]time.Duration{
+		minuteRateLimitCode:  time.Minute,
+		dailyRateLimitCode:   24 * time.Hour,
+		monthlyRateLimitCode: nextMonth(now).Sub(now),
+	} {
+		retryAfter, ok := tokenrate.RetryAfter(apiError(tooMany, &status{ErrorCode: code}))
+		require.True(t, ok)
+		assert.True(t, retryAfter > 0 && retryAfter <= maxWait, code)
+	}
+	assert.Equal(t, tokenrate.ErrUnsupportedTimestamp,
+		errors.Cause(apiError(forbidden, &status{ErrorCode: planNotAuthorizedCode})))
+	assert.Equal(t, tokenrate.ErrUnsupportedCurrency,
+		errors.Cause(apiError(&http.Response{StatusCode: http.StatusBadRequest},
+			&status{ErrorCode: 400, ErrorMessage: `Invalid value for "convert": "XYZ"`})))
+}
@@ -0,0 +1,28 @@
+package coinmarketcap
+
+import (
+	"github.com/urfave/cli"
+
+	"github.com/KyberNetwork/tokenrate/common"
+	"github.com/KyberNetwork/tokenrate/pkg/httpclient"
+)
+
+const (
+	keyFlag = "coinmarketcap-key"
+)
+
+// NewFlags return cli config for coinmarketcap
+func NewFlags() []cli.Flag {
+	return append([]cli.Flag{
+		cli.StringFlag{
+			Name:   keyFlag,
+			Usage:  "CoinMarketCap API Key",
+			EnvVar: "COINMARKETCAP_KEY",
+		},
+	}, httpclient.NewFlags(common.CoinMarketCap, defaultBaseURL)...)
+}
+
+// NewCoinMarketCapFromContext return coinmarketcap provider
+func NewCoinMarketCapFromContext(c *cli.Context) *CoinMarketCap {
+	return New(c.String(keyFlag), httpclient.NewOptionsFromContext(c, common.CoinMarketCap)...)
+}
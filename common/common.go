@@ -5,12 +5,24 @@ const (
 	ETHID = "ETH"
 	// USDID id of usd
 	USDID = "USD"
+	// EURID id of eur
+	EURID = "EUR"
 )
 const (
 	// Coingecko coingecko provider
 	Coingecko = "coingecko"
 	// CoinLib provider
 	CoinLib = "coinlib"
+	// Binance provider
+	Binance = "binance"
+	// Coinbase provider
+	Coinbase = "coinbase"
+	// CryptoCompare provider
+	CryptoCompare = "cryptocompare"
+	// CoinMarketCap provider
+	CoinMarketCap = "coinmarketcap"
+	// Kraken provider
+	Kraken = "kraken"
 )
 
 // PriceResponse ...
@@ -21,3 +33,22 @@ type PriceResponse struct {
 	Error    string  `json:"error,omitempty"`
 	Price    float64 `json:"price"`
 }
+
+// Candle is the daily candle of CandlesResponse.
+type Candle struct {
+	Date   string  `json:"date"`
+	Open   float64 `json:"open"`
+	High   float64 `json:"high"`
+	Low    float64 `json:"low"`
+	Close  float64 `json:"close"`
+	Volume float64 `json:"volume"`
+}
+
+// CandlesResponse ...
+type CandlesResponse struct {
+	Token    string   `json:"token,omitempty"`
+	Currency string   `json:"currency,omitempty"`
+	Failed   bool     `json:"failed"`
+	Error    string   `json:"error,omitempty"`
+	Candles  []Candle `json:"candles"`
+}
@@ -20,3 +20,8 @@ func TimeToDateString(t time.Time) string {
 func TimeOfTodayStart() time.Time {
 	return time.Now().Truncate(time.Hour * 24).UTC()
 }
+
+// IsToday returns true if given timestamp is in current UTC date.
+func IsToday(timestamp time.Time) bool {
+	return TimeOfTodayStart().Equal(timestamp.UTC().Truncate(time.Hour * 24))
+}
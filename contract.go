@@ -0,0 +1,19 @@
+package tokenrate
+
+import (
+	"context"
+	"time"
+)
+
+// ContractProvider is the optional interface implemented by providers
+// that are able to query rates of tokens by their contract address, so
+// long tail tokens can be priced without knowing provider specific
+// IDs.
+type ContractProvider interface {
+	// RateByContract returns the rate of the token deployed at given
+	// address on given chain, e.g ethereum, in real world currency at
+	// given timestamp.
+	RateByContract(ctx context.Context, chain, address, currency string, timestamp time.Time) (float64, error)
+	// Name return name of provider
+	Name() string
+}
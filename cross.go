@@ -0,0 +1,205 @@
+package tokenrate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNoRatePath is returned by CrossRateProvider when neither a direct
+// rate nor a path through the bridge assets is available.
+var ErrNoRatePath = errors.New("no rate path")
+
+// Leg is a single rate of a cross rate path.
+type Leg struct {
+	From     string
+	To       string
+	Provider string
+	// Rate is the rate of From in To.
+	Rate float64
+	// Inverted is true if Rate is the inverse of the rate of To in
+	// From answered by the provider.
+	Inverted bool
+}
+
+func (l Leg) String() string {
+	s := fmt.Sprintf("%s/%s@%s", l.From, l.To, l.Provider)
+	if l.Inverted {
+		s += "(inverted)"
+	}
+	return s
+}
+
+// Path is the legs a cross rate is derived from, the rate is the
+// product of the legs rates.
+type Path []Leg
+
+func (p Path) String() string {
+	legs := make([]string, 0, len(p))
+	for _, l := range p {
+		legs = append(legs, l.String())
+	}
+	return strings.Join(legs, " x ")
+}
+
+// CrossRateProvider is a Provider deriving the rate of A in C as A/B x
+// B/C through the bridge assets B when no provider quotes A in C
+// directly, e.g KNC/EUR = KNC/ETH x ETH/EUR. Inverse rates are used
+// when only the opposite direction is quoted, e.g USD/ETH = 1 / ETH/USD.
+// All providers must understand the same token and currency IDs, e.g
+// by wrapping them with the registry package.
+type CrossRateProvider struct {
+	providers []Provider
+	bridges   []string
+}
+
+// NewCrossRateProvider creates a new CrossRateProvider of given
+// providers and bridge assets, both in order of preference.
+func NewCrossRateProvider(providers []Provider, bridges []string) *CrossRateProvider {
+	return &CrossRateProvider{providers: providers, bridges: bridges}
+}
+
+// Rate returns the direct or derived rate of token in currency.
+func (c *CrossRateProvider) Rate(token, currency string, timestamp time.Time) (float64, error) {
+	return c.RateContext(context.Background(), token, currency, timestamp)
+}
+
+// RateContext is like Rate but the queries are bound to given context.
+func (c *CrossRateProvider) RateContext(ctx context.Context, token, currency string, timestamp time.Time) (float64, error) {
+	rate, _, err := c.RateWithPath(ctx, token, currency, timestamp)
+	return rate, err
+}
+
+// RateWithPath is like RateContext but it also returns the path the
+// rate is derived from. If no path is found and some legs failed for
+// other reasons than not being quoted, e.g rate limited, the first such
+// failure is returned, preferring retryable ones, with all failures in
+// its message.
+func (c *CrossRateProvider) RateWithPath(ctx context.Context, token, currency string, timestamp time.Time) (float64, Path, error) {
+	q := &crossQuery{c: c, timestamp: timestamp, legs: make(map[[2]string]*Leg)}
+	leg, err := q.leg(ctx, token, currency)
+	if err != nil {
+		return 0, nil, err
+	}
+	if leg != nil {
+		return leg.Rate, Path{*leg}, nil
+	}
+	for _, bridge := range c.bridges {
+		if strings.EqualFold(bridge, token) || strings.EqualFold(bridge, currency) {
+			continue
+		}
+		first, err := q.leg(ctx, token, bridge)
+		if err != nil {
+			return 0, nil, err
+		}
+		if first == nil {
+			continue
+		}
+		second, err := q.leg(ctx, bridge, currency)
+		if err != nil {
+			return 0, nil, err
+		}
+		if second == nil {
+			continue
+		}
+		return first.Rate * second.Rate, Path{*first, *second}, nil
+	}
+	if err := q.failure(); err != nil {
+		return 0, nil, errors.Wrapf(errors.Cause(err), "no rate path of %s/%s: %s", token, currency, q.failures())
+	}
+	return 0, nil, errors.Wrapf(ErrNoRatePath, "%s/%s via [%s]", token, currency, strings.Join(c.bridges, ","))
+}
+
+// Name return names of the providers.
+func (c *CrossRateProvider) Name() string {
+	return compositeName("cross", c.providers)
+}
+
+// crossQuery memoizes the legs queried for a single cross rate query.
+type crossQuery struct {
+	c         *CrossRateProvider
+	timestamp time.Time
+	legs      map[[2]string]*Leg
+	errs      []ProviderError
+}
+
+// leg returns the rate of from in to, directly or inverted, nil if no
+// provider answers. The error is not nil only if ctx is done.
+func (q *crossQuery) leg(ctx context.Context, from, to string) (*Leg, error) {
+	key := [2]string{from, to}
+	if leg, ok := q.legs[key]; ok {
+		return leg, nil
+	}
+	leg, err := q.query(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	q.legs[key] = leg
+	return leg, nil
+}
+
+func (q *crossQuery) query(ctx context.Context, from, to string) (*Leg, error) {
+	for _, p := range q.c.providers {
+		rate, err := q.rate(ctx, p, from, to)
+		if err != nil {
+			return nil, err
+		}
+		if rate > 0 {
+			return &Leg{From: from, To: to, Provider: p.Name(), Rate: rate}, nil
+		}
+	}
+	for _, p := range q.c.providers {
+		rate, err := q.rate(ctx, p, to, from)
+		if err != nil {
+			return nil, err
+		}
+		if rate > 0 {
+			return &Leg{From: from, To: to, Provider: p.Name(), Rate: 1 / rate, Inverted: true}, nil
+		}
+	}
+	return nil, nil
+}
+
+// rate queries the rate of token in currency from p, a failure is
+// recorded and zero is returned. The error is ctx.Err() if ctx is done.
+func (q *crossQuery) rate(ctx context.Context, p Provider, token, currency string) (float64, error) {
+	rate, err := RateContext(ctx, p, token, currency, q.timestamp)
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+	if err != nil {
+		q.errs = append(q.errs, ProviderError{Provider: p.Name(), Err: errors.Wrapf(err, "%s/%s", token, currency)})
+		return 0, nil
+	}
+	return rate, nil
+}
+
+// failure returns the first recorded failure that is not about an
+// unquoted pair, preferring retryable ones, nil if there is none.
+func (q *crossQuery) failure() error {
+	for _, e := range q.errs {
+		if IsRetryable(e.Err) {
+			return e.Err
+		}
+	}
+	for _, e := range q.errs {
+		switch errors.Cause(e.Err) {
+		case ErrUnsupportedToken, ErrUnsupportedCurrency, ErrUnsupportedTimestamp:
+		default:
+			return e.Err
+		}
+	}
+	return nil
+}
+
+// failures returns the message listing all recorded failures.
+func (q *crossQuery) failures() string {
+	msgs := make([]string, 0, len(q.errs))
+	for _, e := range q.errs {
+		msgs = append(msgs, e.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
@@ -0,0 +1,91 @@
+package tokenrate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pairProvider struct {
+	name  string
+	rates map[string]float64
+}
+
+func (p *pairProvider) Rate(token, currency string, timestamp time.Time) (float64, error) {
+	rate, ok := p.rates[token+"/"+currency]
+	if !ok {
+		return 0, ErrUnsupportedCurrency
+	}
+	return rate, nil
+}
+
+func (p *pairProvider) Name() string {
+	return p.name
+}
+
+func TestCrossRateProvider(t *testing.T) {
+	c := NewCrossRateProvider([]Provider{
+		&pairProvider{name: "dex", rates: map[string]float64{"KNC/ETH": 0.002}},
+		&pairProvider{name: "fiat", rates: map[string]float64{"ETH/EUR": 150, "ETH/USD": 200}},
+	}, []string{"BTC", "ETH"})
+
+	rate, path, err := c.RateWithPath(context.Background(), "KNC", "EUR", time.Now())
+	require.NoError(t, err)
+	assert.InDelta(t, 0.3, rate, 1e-9)
+	assert.Equal(t, "KNC/ETH@dex x ETH/EUR@fiat", path.String())
+
+	rate, path, err = c.RateWithPath(context.Background(), "USD", "ETH", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 0.005, rate)
+	require.Len(t, path, 1)
+	assert.True(t, path[0].Inverted)
+
+	rate, path, err = c.RateWithPath(context.Background(), "EUR", "KNC", time.Now())
+	require.NoError(t, err)
+	assert.InDelta(t, 1/0.3, rate, 1e-9)
+	assert.Equal(t, "EUR/ETH@fiat(inverted) x ETH/KNC@dex(inverted)", path.String())
+
+	_, err = c.Rate("KNC", "JPY", time.Now())
+	assert.Equal(t, ErrNoRatePath, errors.Cause(err))
+}
+
+type failingProvider struct {
+	err error
+}
+
+func (f failingProvider) Rate(token, currency string, timestamp time.Time) (float64, error) {
+	return 0, f.err
+}
+
+func (f failingProvider) Name() string {
+	return "failing"
+}
+
+func TestCrossRateProviderErrors(t *testing.T) {
+	dex := &pairProvider{name: "dex", rates: map[string]float64{"KNC/ETH": 0.002}}
+	c := NewCrossRateProvider([]Provider{
+		dex,
+		failingProvider{err: &ErrRateLimited{RetryAfter: time.Second}},
+	}, []string{"ETH"})
+	_, err := c.Rate("KNC", "EUR", time.Now())
+	retryAfter, ok := RetryAfter(err)
+	require.True(t, ok)
+	assert.Equal(t, time.Second, retryAfter)
+	assert.True(t, IsRetryable(err))
+
+	c = NewCrossRateProvider([]Provider{
+		dex,
+		failingProvider{err: errors.New("not available")},
+	}, []string{"ETH"})
+	_, err = c.Rate("KNC", "EUR", time.Now())
+	assert.EqualError(t, errors.Cause(err), "not available")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.RateContext(ctx, "KNC", "EUR", time.Now())
+	assert.Equal(t, context.Canceled, err)
+}
@@ -0,0 +1,259 @@
+package cryptocompare
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/KyberNetwork/tokenrate"
+	"github.com/KyberNetwork/tokenrate/common"
+	"github.com/KyberNetwork/tokenrate/pkg/httpclient"
+)
+
+const (
+	defaultBaseURL    = "https://min-api.cryptocompare.com"
+	histoHourEndpoint = "%s/data/v2/histohour"
+	// maxLimit is the maximum number of points of a single request.
+	maxLimit = 2000
+	day      = 24 * time.Hour
+)
+
+const (
+	remainingHeader = "X-RateLimit-Remaining"
+	resetHeader     = "X-RateLimit-Reset"
+)
+
+// CryptoCompare is the CryptoCompare implementation of Provider.
+// Tokens and currencies are the CryptoCompare symbols, e.g. ETH, KNC,
+// USD. Historical rates are the open price of the hour starting the
+// day at 00:00 UTC, the same sample RateRange returns for that time.
+// Today rate is the close price of the last hour.
+type CryptoCompare struct {
+	client  *http.Client
+	key     string
+	baseURL string
+
+	mu         sync.Mutex
+	quota      tokenrate.Quota
+	quotaKnown bool
+}
+
+// New creates a new CryptoCompare instance with given API key, the
+// key is optional for low volume usage.
+func New(key string, opts ...httpclient.Option) *CryptoCompare {
+	cfg := httpclient.NewConfig(defaultBaseURL, opts...)
+	return &CryptoCompare{
+		client:  cfg.Client,
+		key:     key,
+		baseURL: cfg.BaseURL,
+	}
+}
+
+// histoResponse is the response of histohour endpoint.
+type histoResponse struct {
+	Response string `json:"Response"`
+	Message  string `json:"Message"`
+	Data     struct {
+		Data []histoPoint `json:"Data"`
+	} `json:"Data"`
+}
+
+type histoPoint struct {
+	Time  int64   `json:"time"`
+	Open  float64 `json:"open"`
+	High  float64 `json:"high"`
+	Low   float64 `json:"low"`
+	Close float64 `json:"close"`
+}
+
+// Rate returns the rate of given token in given currency at given timestamp.
+func (cc *CryptoCompare) Rate(token, currency string, timestamp time.Time) (float64, error) {
+	return cc.RateContext(context.Background(), token, currency, timestamp)
+}
+
+// RateContext is like Rate but the request is bound to given context.
+func (cc *CryptoCompare) RateContext(ctx context.Context, token, currency string, timestamp time.Time) (float64, error) {
+	if common.IsToday(timestamp) {
+		points, err := cc.histo(ctx, histoHourEndpoint, token, currency, time.Now(), 1)
+		if err != nil {
+			return 0, err
+		}
+		if len(points) == 0 {
+			return 0, errors.Wrapf(tokenrate.ErrUnsupportedTimestamp, "no price of %s/%s today", token, currency)
+		}
+		return points[len(points)-1].Close, nil
+	}
+	start := timestamp.UTC().Truncate(day)
+	points, err := cc.histo(ctx, histoHourEndpoint, token, currency, start, 1)
+	if err != nil {
+		return 0, err
+	}
+	for _, p := range points {
+		// points before the token is listed are zero
+		if p.Time == start.Unix() && p.Open != 0 {
+			return p.Open, nil
+		}
+	}
+	return 0, errors.Wrapf(tokenrate.ErrUnsupportedTimestamp, "no price of %s/%s at %s", token, currency, common.TimeToDateString(start))
+}
+
+// USDRate returns the historical price of ETH.
+func (cc *CryptoCompare) USDRate(timestamp time.Time) (float64, error) {
+	return cc.USDRateContext(context.Background(), timestamp)
+}
+
+// USDRateContext is like USDRate but the request is bound to given context.
+func (cc *CryptoCompare) USDRateContext(ctx context.Context, timestamp time.Time) (float64, error) {
+	return cc.RateContext(ctx, common.ETHID, common.USDID, timestamp)
+}
+
+// RateRange returns the hourly open prices of given token in given
+// currency between from and to.
+func (cc *CryptoCompare) RateRange(ctx context.Context, token, currency string, from, to time.Time) ([]tokenrate.RatePoint, error) {
+	from = from.Truncate(time.Hour)
+	var points []tokenrate.RatePoint
+	// histohour returns the points up to toTs, the range is queried backward
+	for end := to; !end.Before(from); {
+		limit := int(end.Sub(from)/time.Hour) + 1
+		if limit > maxLimit {
+			limit = maxLimit
+		}
+		chunk, err := cc.histo(ctx, histoHourEndpoint, token, currency, end, limit)
+		if err != nil {
+			return nil, err
+		}
+		var earliest = end
+		for i := len(chunk) - 1; i >= 0; i-- {
+			t := time.Unix(chunk[i].Time, 0).UTC()
+			if t.Before(from) || t.After(end) {
+				continue
+			}
+			if t.Before(earliest) {
+				earliest = t
+			}
+			if chunk[i].Open == 0 {
+				continue
+			}
+			points = append(points, tokenrate.RatePoint{Timestamp: t, Rate: chunk[i].Open})
+		}
+		if !earliest.Before(end) {
+			break
+		}
+		end = earliest.Add(-time.Hour)
+	}
+	// reverse to ascending order
+	for i, j := 0, len(points)-1; i < j; i, j = i+1, j-1 {
+		points[i], points[j] = points[j], points[i]
+	}
+	return points, nil
+}
+
+// USDRateRange returns the ETH/USD rates between from and to.
+func (cc *CryptoCompare) USDRateRange(ctx context.Context, from, to time.Time) ([]tokenrate.RatePoint, error) {
+	return cc.RateRange(ctx, common.ETHID, common.USDID, from, to)
+}
+
+// Name returns common.CryptoCompare.
+func (cc *CryptoCompare) Name() string {
+	return common.CryptoCompare
+}
+
+// Quota returns the remaining requests of the API key as reported by
+// the rate limit headers of the last response.
+func (cc *CryptoCompare) Quota() (tokenrate.Quota, bool) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	return cc.quota, cc.quotaKnown
+}
+
+// histo queries given histo endpoint for limit points up to toTs. The
+// response has limit+1 points in ascending order.
+func (cc *CryptoCompare) histo(ctx context.Context, endpoint, token, currency string, toTs time.Time, limit int) ([]histoPoint, error) {
+	q := url.Values{}
+	q.Add("fsym", strings.ToUpper(token))
+	q.Add("tsym", strings.ToUpper(currency))
+	q.Add("toTs", strconv.FormatInt(toTs.Unix(), 10))
+	q.Add("limit", strconv.Itoa(limit))
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(endpoint, cc.baseURL), nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.URL.RawQuery = q.Encode()
+	if len(cc.key) != 0 {
+		req.Header.Add("Authorization", "Apikey "+cc.key)
+	}
+	rsp, err := cc.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+	cc.updateQuota(rsp.Header)
+
+	if rsp.StatusCode != http.StatusOK {
+		err := tokenrate.NewUpstreamError(rsp)
+		if rl, ok := err.(*tokenrate.ErrRateLimited); ok && rl.RetryAfter == 0 {
+			rl.RetryAfter = resetAfter(rsp.Header)
+		}
+		return nil, err
+	}
+	var histo histoResponse
+	if err := json.NewDecoder(rsp.Body).Decode(&histo); err != nil {
+		return nil, err
+	}
+	if histo.Response != "Success" {
+		return nil, apiError(histo.Message, rsp.Header)
+	}
+	return histo.Data.Data, nil
+}
+
+// apiError returns the error of the error message of a response with
+// given headers, the API reports errors with status 200.
+func apiError(msg string, h http.Header) error {
+	lower := strings.ToLower(msg)
+	switch {
+	case strings.Contains(lower, "rate limit"):
+		return errors.Wrap(&tokenrate.ErrRateLimited{RetryAfter: resetAfter(h)}, msg)
+	case strings.Contains(lower, "fsym"), strings.Contains(lower, "market does not exist"):
+		return errors.Wrap(tokenrate.ErrUnsupportedToken, msg)
+	case strings.Contains(lower, "tsym"):
+		return errors.Wrap(tokenrate.ErrUnsupportedCurrency, msg)
+	default:
+		return errors.Errorf("cryptocompare error: %s", msg)
+	}
+}
+
+// updateQuota updates the known quota from the rate limit headers,
+// X-RateLimit-Reset is the number of seconds until the window ends.
+func (cc *CryptoCompare) updateQuota(h http.Header) {
+	remaining, err := strconv.Atoi(h.Get(remainingHeader))
+	if err != nil {
+		return
+	}
+	quota := tokenrate.Quota{Remaining: remaining}
+	if reset := resetAfter(h); reset > 0 {
+		quota.Reset = time.Now().Add(reset)
+	}
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.quota = quota
+	cc.quotaKnown = true
+}
+
+// resetAfter returns the duration until the rate limit window ends
+// from X-RateLimit-Reset header, zero if unknown.
+func resetAfter(h http.Header) time.Duration {
+	reset, err := strconv.Atoi(h.Get(resetHeader))
+	if err != nil || reset <= 0 {
+		return 0
+	}
+	return time.Duration(reset) * time.Second
+}
@@ -0,0 +1,192 @@
+package cryptocompare
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/tokenrate"
+	"github.com/KyberNetwork/tokenrate/pkg/httpclient"
+)
+
+// histoJSON returns a successful histo response of the hourly points with open price i+1 and close price i+1.5 ending at toTs.
+func histoJSON(toTs int64, limit int, interval time.Duration) string {
+	var points []string
+	for i := 0; i <= limit; i++ {
+		t := toTs - int64(limit-i)*int64(interval/time.Second)
+		points = append(points, fmt.Sprintf(`{"time":%d,"open":%d,"high":0,"low":0,"close":%d.5}`, t, i+1, i+1))
+	}
+	return `{"Response":"Success","Message":"","Data":{"Data":[` + strings.Join(points, ",") + `]}}`
+}
+
+func TestCryptoCompare(t *testing.T) {
+	day := time.Date(2019, 10, 1, 0, 0, 0, 0, time.UTC)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); len(auth) != 0 {
+			require.Equal(t, "Apikey secret", auth)
+		}
+		q := r.URL.Query()
+		if q.Get("fsym") != "ETH" {
+			_, _ = w.Write([]byte(`{"Response":"Error","Message":"fsym param is invalid.","Data":{}}`))
+			return
+		}
+		if q.Get("tsym") == "JPY" {
+			w.Header().Set("X-RateLimit-Reset", "30")
+			_, _ = w.Write([]byte(`{"Response":"Error","Message":"You are over your rate limit please upgrade your account!","Data":{}}`))
+			return
+		}
+		w.Header().Set("X-RateLimit-Remaining", "99")
+		w.Header().Set("X-RateLimit-Reset", "60")
+		toTs, err := strconv.ParseInt(q.Get("toTs"), 10, 64)
+		require.NoError(t, err)
+		limit, err := strconv.Atoi(q.Get("limit"))
+		require.NoError(t, err)
+		switch r.URL.Path {
+		case "/data/v2/histohour":
+			_, _ = w.Write([]byte(histoJSON(toTs-toTs%3600, limit, time.Hour)))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	cc := New("secret", httpclient.WithBaseURL(ts.URL))
+
+	_, ok := cc.Quota()
+	assert.False(t, ok)
+
+	rate, err := cc.USDRate(day.Add(10 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, rate)
+
+	quota, ok := cc.Quota()
+	require.True(t, ok)
+	assert.Equal(t, 99, quota.Remaining)
+	assert.True(t, quota.Reset.After(time.Now()))
+
+	rate, err = cc.RateContext(context.Background(), "eth", "usd", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 2.5, rate)
+
+	_, err = cc.Rate("XYZ", "USD", day)
+	assert.Equal(t, tokenrate.ErrUnsupportedToken, errors.Cause(err))
+
+	_, err = cc.Rate("ETH", "JPY", day)
+	retryAfter, ok := tokenrate.RetryAfter(err)
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Second, retryAfter)
+	assert.True(t, tokenrate.IsRetryable(err))
+}
+
+func TestCryptoCompareRateRange(t *testing.T) {
+	var (
+		from     = time.Date(2019, 10, 1, 0, 0, 0, 0, time.UTC)
+		to       = from.Add(2500 * time.Hour)
+		requests int
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		require.Equal(t, "/data/v2/histohour", r.URL.Path)
+		toTs, err := strconv.ParseInt(r.URL.Query().Get("toTs"), 10, 64)
+		require.NoError(t, err)
+		limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+		require.NoError(t, err)
+		require.True(t, limit <= maxLimit)
+		_, _ = w.Write([]byte(histoJSON(toTs, limit, time.Hour)))
+	}))
+	defer ts.Close()
+
+	cc := New("", httpclient.WithBaseURL(ts.URL))
+
+	points, err := cc.USDRateRange(context.Background(), from, to)
+	require.NoError(t, err)
+	assert.Equal(t, 2, requests)
+	require.Len(t, points, 2501)
+	assert.True(t, points[0].Timestamp.Equal(from))
+	assert.True(t, points[len(points)-1].Timestamp.Equal(to))
+	for i := 1; i < len(points); i++ {
+		assert.Equal(t, time.Hour, points[i].Timestamp.Sub(points[i-1].Timestamp))
+	}
+}
+
+func TestCryptoCompareRateMatchesRange(t *testing.T) {
+	// the open price of each hour is its number of hours since epoch
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/data/v2/histohour", r.URL.Path)
+		toTs, err := strconv.ParseInt(r.URL.Query().Get("toTs"), 10, 64)
+		require.NoError(t, err)
+		limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+		require.NoError(t, err)
+		var points []string
+		for hour := toTs/3600 - int64(limit); hour <= toTs/3600; hour++ {
+			points = append(points, fmt.Sprintf(`{"time":%d,"open":%d,"close":%d}`, hour*3600, hour, hour+1))
+		}
+		_, _ = w.Write([]byte(`{"Response":"Success","Data":{"Data":[` + strings.Join(points, ",") + `]}}`))
+	}))
+	defer ts.Close()
+
+	var (
+		cc  = New("", httpclient.WithBaseURL(ts.URL))
+		day = time.Date(2019, 10, 1, 0, 0, 0, 0, time.UTC)
+	)
+	rate, err := cc.USDRate(day.Add(15 * time.Hour))
+	require.NoError(t, err)
+	points, err := cc.USDRateRange(context.Background(), day.Add(-12*time.Hour), day.Add(12*time.Hour))
+	require.NoError(t, err)
+	point, ok := tokenrate.NearestPoint(points, day)
+	require.True(t, ok)
+	assert.True(t, point.Timestamp.Equal(day))
+	assert.Equal(t, point.Rate, rate)
+}
+
+func TestCryptoCompareTooManyRequests(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", "45")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	cc := New("", httpclient.WithBaseURL(ts.URL))
+	_, err := cc.USDRate(time.Date(2019, 10, 1, 0, 0, 0, 0, time.UTC))
+	retryAfter, ok := tokenrate.RetryAfter(err)
+	require.True(t, ok)
+	assert.Equal(t, 45*time.Second, retryAfter)
+
+	quota, ok := cc.Quota()
+	require.True(t, ok)
+	assert.Equal(t, 0, quota.Remaining)
+}
+
+func TestAPIError(t *testing.T) {
+	var tests = []struct {
+		msg   string
+		cause error
+	}{
+		{msg: "fsym param is invalid.", cause: tokenrate.ErrUnsupportedToken},
+		{msg: "cccagg market does not exist for this coin pair (XYZ-USD)", cause: tokenrate.ErrUnsupportedToken},
+		{msg: "tsym param is invalid.", cause: tokenrate.ErrUnsupportedCurrency},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.cause, errors.Cause(apiError(tc.msg, http.Header{})), tc.msg)
+	}
+
+	h := http.Header{}
+	h.Set("X-RateLimit-Reset", "10")
+	retryAfter, ok := tokenrate.RetryAfter(apiError("You are over your rate limit please upgrade your account!", h))
+	require.True(t, ok)
+	assert.Equal(t, 10*time.Second, retryAfter)
+
+	err := apiError("Something went wrong", http.Header{})
+	assert.False(t, tokenrate.IsRetryable(err))
+	assert.Contains(t, err.Error(), "Something went wrong")
+}
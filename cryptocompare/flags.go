@@ -0,0 +1,28 @@
+package cryptocompare
+
+import (
+	"github.com/urfave/cli"
+
+	"github.com/KyberNetwork/tokenrate/common"
+	"github.com/KyberNetwork/tokenrate/pkg/httpclient"
+)
+
+const (
+	keyFlag = "cryptocompare-key"
+)
+
+// NewFlags return cli config for cryptocompare
+func NewFlags() []cli.Flag {
+	return append([]cli.Flag{
+		cli.StringFlag{
+			Name:   keyFlag,
+			Usage:  "CryptoCompare API Key",
+			EnvVar: "CRYPTOCOMPARE_KEY",
+		},
+	}, httpclient.NewFlags(common.CryptoCompare, defaultBaseURL)...)
+}
+
+// NewCryptoCompareFromContext return cryptocompare provider
+func NewCryptoCompareFromContext(c *cli.Context) *CryptoCompare {
+	return New(c.String(keyFlag), httpclient.NewOptionsFromContext(c, common.CryptoCompare)...)
+}
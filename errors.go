@@ -0,0 +1,120 @@
+package tokenrate
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrUnsupportedToken is returned when the provider does not know
+	// the queried token.
+	ErrUnsupportedToken = errors.New("unsupported token")
+	// ErrUnsupportedCurrency is returned when the provider does not
+	// quote the queried token in the queried currency.
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+	// ErrUnsupportedTimestamp is returned when the provider can not
+	// answer for the queried timestamp, e.g a provider that only knows
+	// today price.
+	ErrUnsupportedTimestamp = errors.New("unsupported timestamp")
+)
+
+// ErrRateLimited is returned when the provider API rejects the query
+// because the rate limit is exceeded.
+type ErrRateLimited struct {
+	// RetryAfter is the duration to wait before retrying, zero if the
+	// provider did not tell.
+	RetryAfter time.Duration
+}
+
+func (e *ErrRateLimited) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("rate limited, retry after %s", e.RetryAfter)
+	}
+	return "rate limited"
+}
+
+// ErrUpstream is returned when the provider API responses with an
+// unexpected status code.
+type ErrUpstream struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *ErrUpstream) Error() string {
+	return fmt.Sprintf("unexpected status code: %s", e.Status)
+}
+
+// NewUpstreamError returns the error describing the unexpected
+// response rsp. The returned error is ErrRateLimited for status 429,
+// ErrUpstream otherwise.
+func NewUpstreamError(rsp *http.Response) error {
+	if rsp.StatusCode == http.StatusTooManyRequests {
+		return &ErrRateLimited{RetryAfter: ParseRetryAfter(rsp.Header.Get("Retry-After"))}
+	}
+	status := rsp.Status
+	if len(status) == 0 {
+		status = fmt.Sprintf("%d %s", rsp.StatusCode, http.StatusText(rsp.StatusCode))
+	}
+	return &ErrUpstream{StatusCode: rsp.StatusCode, Status: status}
+}
+
+// ParseRetryAfter parses the value of Retry-After HTTP header, which is
+// either a number of seconds or a HTTP date. It returns zero if the
+// value is empty or malformed.
+func ParseRetryAfter(v string) time.Duration {
+	if len(v) == 0 {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(v); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// RetryAfter returns the duration the provider asked to wait before
+// retrying if err is caused by rate limit.
+func RetryAfter(err error) (time.Duration, bool) {
+	rl, ok := errors.Cause(err).(*ErrRateLimited)
+	if !ok {
+		return 0, false
+	}
+	return rl.RetryAfter, true
+}
+
+// IsRetryable reports whether the query failed with err is worth
+// retrying later: rate limit, upstream server errors and network
+// errors. Unsupported queries and context cancellation are not.
+func IsRetryable(err error) bool {
+	switch e := errors.Cause(err).(type) {
+	case nil:
+		return false
+	case *ErrRateLimited:
+		return true
+	case *ErrUpstream:
+		return e.StatusCode >= http.StatusInternalServerError
+	case *url.Error:
+		if e.Err == context.Canceled || e.Err == context.DeadlineExceeded {
+			return false
+		}
+		return true
+	case net.Error:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,50 @@
+package tokenrate
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewUpstreamError(t *testing.T) {
+	rsp := &http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Retry-After": []string{"30"}},
+	}
+	err := errors.Wrap(NewUpstreamError(rsp), "query provider")
+	retryAfter, ok := RetryAfter(err)
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Second, retryAfter)
+	assert.True(t, IsRetryable(err))
+
+	rsp = &http.Response{StatusCode: http.StatusBadGateway, Status: "502 Bad Gateway"}
+	err = NewUpstreamError(rsp)
+	upstream, ok := errors.Cause(err).(*ErrUpstream)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusBadGateway, upstream.StatusCode)
+	assert.True(t, IsRetryable(err))
+
+	rsp = &http.Response{StatusCode: http.StatusBadRequest}
+	assert.False(t, IsRetryable(NewUpstreamError(rsp)))
+}
+
+func TestIsRetryable(t *testing.T) {
+	var tests = []struct {
+		err       error
+		retryable bool
+	}{
+		{err: nil, retryable: false},
+		{err: errors.Wrap(ErrUnsupportedToken, "coin not found"), retryable: false},
+		{err: ErrUnsupportedTimestamp, retryable: false},
+		{err: &url.Error{Op: "Get", URL: "http://localhost", Err: context.Canceled}, retryable: false},
+		{err: &url.Error{Op: "Get", URL: "http://localhost", Err: errors.New("connection refused")}, retryable: true},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.retryable, IsRetryable(tc.err), "%v", tc.err)
+	}
+}
@@ -0,0 +1,133 @@
+package tokenrate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ProviderError is the failure of a single provider in a composite
+// query.
+type ProviderError struct {
+	Provider string
+	Err      error
+}
+
+func (e ProviderError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Provider, e.Err)
+}
+
+// FallbackError is returned by FallbackProvider when all providers
+// failed, it lists the failure of each provider in order.
+type FallbackError struct {
+	Errors []ProviderError
+}
+
+func (e *FallbackError) Error() string {
+	if len(e.Errors) == 0 {
+		return "no provider available"
+	}
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("all providers failed: %s", strings.Join(msgs, "; "))
+}
+
+// FallbackOption configures optional behaviour of FallbackProvider.
+type FallbackOption func(*FallbackProvider)
+
+// WithTimeout sets the timeout of each provider query, zero means no
+// timeout other than the caller context.
+func WithTimeout(timeout time.Duration) FallbackOption {
+	return func(f *FallbackProvider) {
+		f.timeout = timeout
+	}
+}
+
+// WithProviderTimeout sets the timeout of queries to the provider with
+// given name, overriding WithTimeout.
+func WithProviderTimeout(name string, timeout time.Duration) FallbackOption {
+	return func(f *FallbackProvider) {
+		f.timeouts[name] = timeout
+	}
+}
+
+// FallbackProvider is a Provider querying the given providers in
+// order, the first successful answer is returned.
+type FallbackProvider struct {
+	providers []Provider
+	timeout   time.Duration
+	timeouts  map[string]time.Duration
+}
+
+// NewFallbackProvider creates a new FallbackProvider of given
+// providers.
+func NewFallbackProvider(providers []Provider, opts ...FallbackOption) *FallbackProvider {
+	f := &FallbackProvider{
+		providers: providers,
+		timeouts:  make(map[string]time.Duration),
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// Rate returns the first successful answer of the providers.
+func (f *FallbackProvider) Rate(token, currency string, timestamp time.Time) (float64, error) {
+	return f.RateContext(context.Background(), token, currency, timestamp)
+}
+
+// RateContext is like Rate but the queries are bound to given context.
+func (f *FallbackProvider) RateContext(ctx context.Context, token, currency string, timestamp time.Time) (float64, error) {
+	rate, _, err := f.RateWithSource(ctx, token, currency, timestamp)
+	return rate, err
+}
+
+// RateWithSource is like RateContext but it also returns the name of
+// the provider that answered. The returned error is a *FallbackError if
+// all providers failed.
+func (f *FallbackProvider) RateWithSource(ctx context.Context, token, currency string, timestamp time.Time) (float64, string, error) {
+	fallbackErr := &FallbackError{}
+	for _, p := range f.providers {
+		rate, err := f.query(ctx, p, token, currency, timestamp)
+		if err == nil {
+			return rate, p.Name(), nil
+		}
+		fallbackErr.Errors = append(fallbackErr.Errors, ProviderError{Provider: p.Name(), Err: err})
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return 0, "", fallbackErr
+}
+
+func (f *FallbackProvider) query(ctx context.Context, p Provider, token, currency string, timestamp time.Time) (float64, error) {
+	timeout, ok := f.timeouts[p.Name()]
+	if !ok {
+		timeout = f.timeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return RateContext(ctx, p, token, currency, timestamp)
+}
+
+// Name return names of the providers.
+func (f *FallbackProvider) Name() string {
+	return compositeName("fallback", f.providers)
+}
+
+// compositeName returns the name of a composite provider, e.g
+// fallback(coingecko,coinlib).
+func compositeName(kind string, providers []Provider) string {
+	names := make([]string, 0, len(providers))
+	for _, p := range providers {
+		names = append(names, p.Name())
+	}
+	return fmt.Sprintf("%s(%s)", kind, strings.Join(names, ","))
+}
@@ -0,0 +1,63 @@
+package tokenrate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fixedProvider struct {
+	name  string
+	rate  float64
+	err   error
+	delay time.Duration
+}
+
+func (f *fixedProvider) Rate(token, currency string, timestamp time.Time) (float64, error) {
+	return f.RateContext(context.Background(), token, currency, timestamp)
+}
+
+func (f *fixedProvider) RateContext(ctx context.Context, token, currency string, timestamp time.Time) (float64, error) {
+	if f.delay > 0 {
+		select {
+		case <-time.After(f.delay):
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+	return f.rate, f.err
+}
+
+func (f *fixedProvider) Name() string {
+	return f.name
+}
+
+func TestFallbackProvider(t *testing.T) {
+	f := NewFallbackProvider([]Provider{
+		&fixedProvider{name: "down", err: errors.New("not available")},
+		&fixedProvider{name: "slow", rate: 1, delay: time.Second},
+		&fixedProvider{name: "fixed", rate: 100},
+	}, WithTimeout(time.Second*5), WithProviderTimeout("slow", time.Millisecond*10))
+	assert.Equal(t, "fallback(down,slow,fixed)", f.Name())
+
+	rate, source, err := f.RateWithSource(context.Background(), "ETH", "USD", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 100.0, rate)
+	assert.Equal(t, "fixed", source)
+
+	f = NewFallbackProvider([]Provider{
+		&fixedProvider{name: "down", err: errors.New("not available")},
+		&fixedProvider{name: "unsupported", err: ErrUnsupportedToken},
+	})
+	_, err = f.Rate("ETH", "USD", time.Now())
+	fallbackErr, ok := err.(*FallbackError)
+	require.True(t, ok)
+	require.Len(t, fallbackErr.Errors, 2)
+	assert.Equal(t, "unsupported", fallbackErr.Errors[1].Provider)
+	assert.Equal(t, ErrUnsupportedToken, fallbackErr.Errors[1].Err)
+	assert.Equal(t, "all providers failed: down: not available; unsupported: unsupported token", err.Error())
+}
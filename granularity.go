@@ -0,0 +1,76 @@
+package tokenrate
+
+import (
+	"context"
+	"time"
+)
+
+// Granularity is the sampling interval of rates.
+type Granularity time.Duration
+
+const (
+	// FiveMinutes is the 5 minutes granularity.
+	FiveMinutes = Granularity(5 * time.Minute)
+	// Hourly is the hourly granularity.
+	Hourly = Granularity(time.Hour)
+	// Daily is the daily granularity, the precision of Provider.
+	Daily = Granularity(24 * time.Hour)
+)
+
+func (g Granularity) String() string {
+	switch g {
+	case FiveMinutes:
+		return "5m"
+	case Hourly:
+		return "1h"
+	case Daily:
+		return "1d"
+	default:
+		return time.Duration(g).String()
+	}
+}
+
+// Sample is a rate with the time it was actually sampled at.
+type Sample struct {
+	Rate float64
+	// Timestamp is the actual sample time, which might differ from the
+	// queried timestamp by up to half of Granularity.
+	Timestamp time.Time
+	// Granularity is the sampling interval used to answer, which might
+	// be coarser than requested if the provider has no finer data.
+	Granularity Granularity
+}
+
+// GranularProvider is the optional interface implemented by providers
+// that are able to answer the rate nearest to a timestamp with a finer
+// precision than a day.
+type GranularProvider interface {
+	// RateAt returns the sample nearest to given timestamp at given
+	// granularity, or the finest coarser granularity available.
+	RateAt(ctx context.Context, token, currency string, timestamp time.Time, granularity Granularity) (Sample, error)
+	// Name return name of provider
+	Name() string
+}
+
+// GuessGranularity returns the granularity of sorted points from the
+// smallest interval between them, false if there are less than two
+// distinct points.
+func GuessGranularity(points []RatePoint) (Granularity, bool) {
+	var interval time.Duration
+	for i := 1; i < len(points); i++ {
+		d := points[i].Timestamp.Sub(points[i-1].Timestamp)
+		if d > 0 && (interval == 0 || d < interval) {
+			interval = d
+		}
+	}
+	switch {
+	case interval == 0:
+		return 0, false
+	case interval <= 2*time.Duration(FiveMinutes):
+		return FiveMinutes, true
+	case interval <= 2*time.Duration(Hourly):
+		return Hourly, true
+	default:
+		return Daily, true
+	}
+}
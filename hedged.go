@@ -0,0 +1,125 @@
+package tokenrate
+
+import (
+	"context"
+	"time"
+)
+
+// HedgedProvider is a Provider querying the first provider and, if it
+// has not answered within the hedge delay, also the next one, and so
+// on. A failed query fires the next one at once. The first successful
+// answer wins and the other queries are cancelled, so the latency is
+// not bounded by the slowest provider.
+type HedgedProvider struct {
+	providers []Provider
+	delay     time.Duration
+	timeout   time.Duration
+}
+
+// HedgedOption configures optional behaviour of HedgedProvider.
+type HedgedOption func(*HedgedProvider)
+
+// WithHedgedTimeout sets the timeout of each provider query, zero
+// means no timeout other than the caller context. A timed out query
+// fires the next one at once like a failed one.
+func WithHedgedTimeout(timeout time.Duration) HedgedOption {
+	return func(h *HedgedProvider) {
+		h.timeout = timeout
+	}
+}
+
+// NewHedgedProvider creates a new HedgedProvider of given providers in
+// order of preference.
+func NewHedgedProvider(providers []Provider, delay time.Duration, opts ...HedgedOption) *HedgedProvider {
+	h := &HedgedProvider{providers: providers, delay: delay}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Rate returns the first successful answer of the providers.
+func (h *HedgedProvider) Rate(token, currency string, timestamp time.Time) (float64, error) {
+	return h.RateContext(context.Background(), token, currency, timestamp)
+}
+
+// RateContext is like Rate but the queries are bound to given context.
+func (h *HedgedProvider) RateContext(ctx context.Context, token, currency string, timestamp time.Time) (float64, error) {
+	rate, _, err := h.RateWithSource(ctx, token, currency, timestamp)
+	return rate, err
+}
+
+// RateWithSource is like RateContext but it also returns the name of
+// the provider that answered. The returned error is a *FallbackError if
+// all providers failed.
+func (h *HedgedProvider) RateWithSource(ctx context.Context, token, currency string, timestamp time.Time) (float64, string, error) {
+	type result struct {
+		p    Provider
+		rate float64
+		err  error
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // cancel the losers
+
+	var (
+		results     = make(chan result, len(h.providers))
+		next        int
+		pending     int
+		hedge       *time.Timer
+		hedgeC      <-chan time.Time
+		fallbackErr = &FallbackError{}
+	)
+	launch := func() {
+		p := h.providers[next]
+		next++
+		pending++
+		go func() {
+			ctx := ctx
+			if h.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, h.timeout)
+				defer cancel()
+			}
+			rate, err := RateContext(ctx, p, token, currency, timestamp)
+			results <- result{p: p, rate: rate, err: err}
+		}()
+		if hedge != nil {
+			hedge.Stop()
+		}
+		hedgeC = nil
+		if next < len(h.providers) {
+			hedge = time.NewTimer(h.delay)
+			hedgeC = hedge.C
+		}
+	}
+	defer func() {
+		if hedge != nil {
+			hedge.Stop()
+		}
+	}()
+
+	if len(h.providers) > 0 {
+		launch()
+	}
+	for pending > 0 {
+		select {
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				return r.rate, r.p.Name(), nil
+			}
+			fallbackErr.Errors = append(fallbackErr.Errors, ProviderError{Provider: r.p.Name(), Err: r.err})
+			if next < len(h.providers) && ctx.Err() == nil {
+				launch()
+			}
+		case <-hedgeC:
+			launch()
+		}
+	}
+	return 0, "", fallbackErr
+}
+
+// Name return names of the providers.
+func (h *HedgedProvider) Name() string {
+	return compositeName("hedged", h.providers)
+}
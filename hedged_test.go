@@ -0,0 +1,75 @@
+package tokenrate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHedgedProvider(t *testing.T) {
+	h := NewHedgedProvider([]Provider{
+		&fixedProvider{name: "slow", rate: 1, delay: time.Second},
+		&fixedProvider{name: "fast", rate: 2, delay: time.Millisecond},
+	}, time.Millisecond*20)
+	start := time.Now()
+	rate, source, err := h.RateWithSource(context.Background(), "ETH", "USD", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, rate)
+	assert.Equal(t, "fast", source)
+	assert.True(t, time.Since(start) < time.Millisecond*500)
+
+	// primary answering within hedge delay wins without hedging
+	h = NewHedgedProvider([]Provider{
+		&fixedProvider{name: "primary", rate: 1, delay: time.Millisecond},
+		&fixedProvider{name: "secondary", rate: 2},
+	}, time.Millisecond*100)
+	_, source, err = h.RateWithSource(context.Background(), "ETH", "USD", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "primary", source)
+
+	// failure fires the next one at once
+	h = NewHedgedProvider([]Provider{
+		&fixedProvider{name: "down", err: errors.New("not available")},
+		&fixedProvider{name: "secondary", rate: 2},
+	}, time.Hour)
+	_, source, err = h.RateWithSource(context.Background(), "ETH", "USD", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "secondary", source)
+
+	h = NewHedgedProvider([]Provider{
+		&fixedProvider{name: "down", err: errors.New("not available")},
+	}, time.Hour)
+	_, err = h.Rate("ETH", "USD", time.Now())
+	fallbackErr, ok := err.(*FallbackError)
+	require.True(t, ok)
+	assert.Len(t, fallbackErr.Errors, 1)
+}
+
+func TestHedgedProviderTimeout(t *testing.T) {
+	h := NewHedgedProvider([]Provider{
+		&fixedProvider{name: "hung", rate: 1, delay: time.Hour},
+		&fixedProvider{name: "slow", rate: 2, delay: time.Second},
+	}, time.Hour, WithHedgedTimeout(time.Millisecond*20))
+	start := time.Now()
+	_, _, err := h.RateWithSource(context.Background(), "ETH", "USD", time.Now())
+	fallbackErr, ok := err.(*FallbackError)
+	require.True(t, ok)
+	require.Len(t, fallbackErr.Errors, 2)
+	assert.Equal(t, "hung", fallbackErr.Errors[0].Provider)
+	assert.Equal(t, context.DeadlineExceeded, fallbackErr.Errors[0].Err)
+	assert.True(t, time.Since(start) < time.Millisecond*500)
+
+	// the timed out query fires the next one before the hedge delay
+	h = NewHedgedProvider([]Provider{
+		&fixedProvider{name: "hung", rate: 1, delay: time.Hour},
+		&fixedProvider{name: "fixed", rate: 2},
+	}, time.Hour, WithHedgedTimeout(time.Millisecond*20))
+	rate, source, err := h.RateWithSource(context.Background(), "ETH", "USD", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, rate)
+	assert.Equal(t, "fixed", source)
+}
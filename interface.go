@@ -1,6 +1,9 @@
 package tokenrate
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // Provider is the common interface to query historical rates of any
 // token to real worldp currencies.
@@ -12,6 +15,14 @@ type Provider interface {
 	Name() string
 }
 
+// ProviderContext is the context aware version of Provider. The
+// query is abandoned as soon as given context is cancelled or its
+// deadline exceeded.
+type ProviderContext interface {
+	Provider
+	RateContext(ctx context.Context, token, currency string, timestamp time.Time) (float64, error)
+}
+
 // ETHUSDRateProvider is the common interface to query historical
 // rates of ETH to USD.
 type ETHUSDRateProvider interface {
@@ -19,3 +30,34 @@ type ETHUSDRateProvider interface {
 	// Name return name of provider
 	Name() string
 }
+
+// ETHUSDRateProviderContext is the context aware version of
+// ETHUSDRateProvider.
+type ETHUSDRateProviderContext interface {
+	ETHUSDRateProvider
+	USDRateContext(ctx context.Context, timestamp time.Time) (float64, error)
+}
+
+// RateContext queries the rate from given provider with ctx if the
+// provider supports it, otherwise the context is ignored.
+func RateContext(ctx context.Context, p Provider, token, currency string, timestamp time.Time) (float64, error) {
+	if pc, ok := p.(ProviderContext); ok {
+		return pc.RateContext(ctx, token, currency, timestamp)
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return p.Rate(token, currency, timestamp)
+}
+
+// USDRateContext queries the ETH/USD rate from given provider with
+// ctx if the provider supports it, otherwise the context is ignored.
+func USDRateContext(ctx context.Context, p ETHUSDRateProvider, timestamp time.Time) (float64, error) {
+	if pc, ok := p.(ETHUSDRateProviderContext); ok {
+		return pc.USDRateContext(ctx, timestamp)
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return p.USDRate(timestamp)
+}
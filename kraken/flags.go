@@ -0,0 +1,18 @@
+package kraken
+
+import (
+	"github.com/urfave/cli"
+
+	"github.com/KyberNetwork/tokenrate/common"
+	"github.com/KyberNetwork/tokenrate/pkg/httpclient"
+)
+
+// NewFlags return cli config for kraken
+func NewFlags() []cli.Flag {
+	return httpclient.NewFlags(common.Kraken, defaultBaseURL)
+}
+
+// NewKrakenFromContext return kraken provider
+func NewKrakenFromContext(c *cli.Context) *Kraken {
+	return New(httpclient.NewOptionsFromContext(c, common.Kraken)...)
+}
@@ -0,0 +1,217 @@
+package kraken
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/KyberNetwork/tokenrate"
+	"github.com/KyberNetwork/tokenrate/common"
+	"github.com/KyberNetwork/tokenrate/pkg/httpclient"
+)
+
+const (
+	defaultBaseURL = "https://api.kraken.com"
+	ohlcEndpoint   = "%s/0/public/OHLC"
+	day            = 24 * time.Hour
+	// maxCandles is the number of most recent candles Kraken keeps for
+	// each interval, older ones can not be queried.
+	maxCandles = 720
+)
+
+// intervals of OHLC endpoint in minutes.
+const (
+	oneMinute = 1
+	oneDay    = 1440
+)
+
+// assetNames maps the canonical symbols to Kraken asset names.
+var assetNames = map[string]string{
+	"BTC":  "XBT",
+	"DOGE": "XDG",
+}
+
+// Kraken is the Kraken implementation of Provider, on top of the public
+// OHLC endpoint. Tokens and currencies are the canonical symbols, e.g.
+// BTC, ETH, USD, EUR. Historical rates are the close price of the day
+// within the last 720 days, today rate is the close price of the last
+// minute.
+type Kraken struct {
+	client  *http.Client
+	baseURL string
+}
+
+// New creates a new Kraken instance.
+func New(opts ...httpclient.Option) *Kraken {
+	cfg := httpclient.NewConfig(defaultBaseURL, opts...)
+	return &Kraken{
+		client:  cfg.Client,
+		baseURL: cfg.BaseURL,
+	}
+}
+
+// ohlcResponse is the response of OHLC endpoint. The result has the
+// candles keyed by the Kraken pair name, e.g. XETHZUSD, and the id of
+// the last candle keyed by "last".
+type ohlcResponse struct {
+	Error  []string                   `json:"error"`
+	Result map[string]json.RawMessage `json:"result"`
+}
+
+// candle is [time, open, high, low, close, vwap, volume, count] with
+// prices and volume as strings.
+type candle struct {
+	Time  int64
+	Close float64
+}
+
+// UnmarshalJSON decodes the time and close price of a candle.
+func (c *candle) UnmarshalJSON(data []byte) error {
+	var fields []interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if len(fields) < 5 {
+		return errors.Errorf("malformed candle: %s", data)
+	}
+	t, ok := fields[0].(float64)
+	if !ok {
+		return errors.Errorf("malformed candle time: %v", fields[0])
+	}
+	s, ok := fields[4].(string)
+	if !ok {
+		return errors.Errorf("malformed candle close: %v", fields[4])
+	}
+	closePrice, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return errors.Wrap(err, "malformed candle close")
+	}
+	c.Time, c.Close = int64(t), closePrice
+	return nil
+}
+
+// Rate returns the rate of given token in given currency at given timestamp.
+func (k *Kraken) Rate(token, currency string, timestamp time.Time) (float64, error) {
+	return k.RateContext(context.Background(), token, currency, timestamp)
+}
+
+// RateContext is like Rate but the request is bound to given context.
+func (k *Kraken) RateContext(ctx context.Context, token, currency string, timestamp time.Time) (float64, error) {
+	if common.IsToday(timestamp) {
+		candles, err := k.ohlc(ctx, pair(token, currency), oneMinute, time.Now().Add(-5*time.Minute))
+		if err != nil {
+			return 0, err
+		}
+		if len(candles) == 0 {
+			return 0, errors.Wrapf(tokenrate.ErrUnsupportedTimestamp, "no candle of %s today", pair(token, currency))
+		}
+		return candles[len(candles)-1].Close, nil
+	}
+
+	start := timestamp.UTC().Truncate(day)
+	if time.Since(start) > maxCandles*day {
+		return 0, errors.Wrapf(tokenrate.ErrUnsupportedTimestamp, "kraken only keeps last %d daily candles", maxCandles)
+	}
+	candles, err := k.ohlc(ctx, pair(token, currency), oneDay, start.Add(-time.Second))
+	if err != nil {
+		return 0, err
+	}
+	for _, c := range candles {
+		if c.Time == start.Unix() {
+			return c.Close, nil
+		}
+	}
+	return 0, errors.Wrapf(tokenrate.ErrUnsupportedTimestamp, "no candle of %s at %s", pair(token, currency), common.TimeToDateString(start))
+}
+
+// USDRate returns the historical price of ETH.
+func (k *Kraken) USDRate(timestamp time.Time) (float64, error) {
+	return k.USDRateContext(context.Background(), timestamp)
+}
+
+// USDRateContext is like USDRate but the request is bound to given context.
+func (k *Kraken) USDRateContext(ctx context.Context, timestamp time.Time) (float64, error) {
+	return k.RateContext(ctx, common.ETHID, common.USDID, timestamp)
+}
+
+// Name returns common.Kraken.
+func (k *Kraken) Name() string {
+	return common.Kraken
+}
+
+// ohlc returns the candles of given interval in minutes since given time.
+func (k *Kraken) ohlc(ctx context.Context, pair string, interval int, since time.Time) ([]candle, error) {
+	q := url.Values{}
+	q.Add("pair", pair)
+	q.Add("interval", strconv.Itoa(interval))
+	q.Add("since", strconv.FormatInt(since.Unix(), 10))
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(ohlcEndpoint, k.baseURL), nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.URL.RawQuery = q.Encode()
+	rsp, err := k.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return nil, tokenrate.NewUpstreamError(rsp)
+	}
+
+	var ohlc ohlcResponse
+	if err := json.NewDecoder(rsp.Body).Decode(&ohlc); err != nil {
+		return nil, err
+	}
+	if len(ohlc.Error) != 0 {
+		return nil, apiError(ohlc.Error)
+	}
+	// the result is keyed by the Kraken pair name which might differ
+	// from the requested one, e.g. XETHZUSD for ETHUSD
+	for name, data := range ohlc.Result {
+		if name == "last" {
+			continue
+		}
+		var candles []candle
+		if err := json.Unmarshal(data, &candles); err != nil {
+			return nil, errors.Wrapf(err, "malformed candles of %s", name)
+		}
+		return candles, nil
+	}
+	return nil, errors.Wrapf(tokenrate.ErrUnsupportedToken, "no candles of %s", pair)
+}
+
+// apiError returns the error of the error messages of a response, e.g.
+// "EQuery:Unknown asset pair".
+func apiError(msgs []string) error {
+	msg := strings.Join(msgs, ", ")
+	switch {
+	case strings.Contains(msg, "Unknown asset pair"):
+		return errors.Wrap(tokenrate.ErrUnsupportedToken, msg)
+	case strings.Contains(msg, "Too many requests"), strings.Contains(msg, "Rate limit exceeded"):
+		return errors.Wrap(&tokenrate.ErrRateLimited{}, msg)
+	default:
+		return errors.Errorf("kraken error: %s", msg)
+	}
+}
+
+// pair returns the Kraken pair of given token and currency, e.g. XBTEUR.
+func pair(token, currency string) string {
+	return assetName(token) + assetName(currency)
+}
+
+func assetName(symbol string) string {
+	symbol = strings.ToUpper(symbol)
+	if name, ok := assetNames[symbol]; ok {
+		return name
+	}
+	return symbol
+}
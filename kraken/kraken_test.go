@@ -0,0 +1,84 @@
+package kraken
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/tokenrate"
+	"github.com/KyberNetwork/tokenrate/pkg/httpclient"
+)
+
+func TestKraken(t *testing.T) {
+	var (
+		today = time.Now().UTC().Truncate(24 * time.Hour)
+		day   = today.Add(-10 * 24 * time.Hour)
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/0/public/OHLC", r.URL.Path)
+		q := r.URL.Query()
+		switch q.Get("pair") {
+		case "ETHUSD":
+			require.Equal(t, "1440", q.Get("interval"))
+			require.Equal(t, fmt.Sprint(day.Unix()-1), q.Get("since"))
+			_, _ = fmt.Fprintf(w, `{"error":[],"result":{"XETHZUSD":[`+
+				`[%d,"180.0","185.0","175.0","182.5","181.0","1000.0",100],`+
+				`[%d,"182.5","190.0","180.0","188.0","185.0","2000.0",200]],"last":%d}}`,
+				day.Unix(), day.Unix()+86400, day.Unix()+86400)
+		case "XBTEUR":
+			require.Equal(t, "1", q.Get("interval"))
+			_, _ = fmt.Fprintf(w, `{"error":[],"result":{"last":%d,"XXBTZEUR":[`+
+				`[%d,"7000.0","7010.0","6990.0","7005.0","7000.0","1.0",10],`+
+				`[%d,"7005.0","7020.0","7000.0","7015.5","7010.0","1.0",10]]}}`,
+				time.Now().Unix(), time.Now().Unix()-60, time.Now().Unix())
+		case "ETHEUR":
+			_, _ = w.Write([]byte(`{"error":["EGeneral:Too many requests"]}`))
+		default:
+			_, _ = w.Write([]byte(`{"error":["EQuery:Unknown asset pair"]}`))
+		}
+	}))
+	defer ts.Close()
+
+	k := New(httpclient.WithBaseURL(ts.URL))
+
+	rate, err := k.USDRate(day.Add(10 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 182.5, rate)
+
+	rate, err = k.RateContext(context.Background(), "btc", "eur", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 7015.5, rate)
+
+	_, err = k.Rate("XYZ", "EUR", time.Now())
+	assert.Equal(t, tokenrate.ErrUnsupportedToken, errors.Cause(err))
+
+	_, err = k.Rate("ETH", "EUR", time.Now())
+	assert.True(t, tokenrate.IsRetryable(err))
+
+	_, err = k.Rate("ETH", "USD", today.Add(-1000*24*time.Hour))
+	assert.Equal(t, tokenrate.ErrUnsupportedTimestamp, errors.Cause(err))
+}
+
+func TestPair(t *testing.T) {
+	var tests = []struct {
+		token    string
+		currency string
+		pair     string
+	}{
+		{token: "ETH", currency: "USD", pair: "ETHUSD"},
+		{token: "btc", currency: "eur", pair: "XBTEUR"},
+		{token: "XBT", currency: "EUR", pair: "XBTEUR"},
+		{token: "DOGE", currency: "USD", pair: "XDGUSD"},
+		{token: "ETH", currency: "BTC", pair: "ETHXBT"},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.pair, pair(tc.token, tc.currency))
+	}
+}
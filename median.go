@@ -0,0 +1,181 @@
+package tokenrate
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Quote is a rate answered by a provider.
+type Quote struct {
+	Provider string
+	Rate     float64
+}
+
+// Consensus is the aggregated answer of MedianProvider.
+type Consensus struct {
+	// Rate is the median of the accepted quotes.
+	Rate float64
+	// Sources are the accepted quotes contributing to Rate.
+	Sources []Quote
+	// Rejected are the quotes discarded as outliers.
+	Rejected []Quote
+}
+
+// QuorumError is returned by MedianProvider when there are not enough
+// accepted quotes.
+type QuorumError struct {
+	Required int
+	Accepted int
+	// Rejected are the quotes discarded as outliers.
+	Rejected []Quote
+	// Errors are the failures of the providers.
+	Errors []ProviderError
+}
+
+func (e *QuorumError) Error() string {
+	return fmt.Sprintf("quorum not reached: %d accepted quotes, %d required, %d rejected, %d failed",
+		e.Accepted, e.Required, len(e.Rejected), len(e.Errors))
+}
+
+// MedianOption configures optional behaviour of MedianProvider.
+type MedianOption func(*MedianProvider)
+
+// WithMaxDeviation discards the quotes deviating from the median of all
+// quotes by more than given percentage. Zero means no quote is
+// discarded.
+func WithMaxDeviation(percent float64) MedianOption {
+	return func(m *MedianProvider) {
+		m.maxDeviation = percent
+	}
+}
+
+// WithMinQuorum requires at least n accepted quotes.
+func WithMinQuorum(n int) MedianOption {
+	return func(m *MedianProvider) {
+		m.quorum = n
+	}
+}
+
+// WithMedianTimeout sets the timeout of each provider query, zero
+// means no timeout other than the caller context. The median is taken
+// from the quotes answered in time.
+func WithMedianTimeout(timeout time.Duration) MedianOption {
+	return func(m *MedianProvider) {
+		m.timeout = timeout
+	}
+}
+
+// MedianProvider is a Provider querying all given providers in parallel
+// and answering the median of their quotes, after discarding outliers.
+type MedianProvider struct {
+	providers    []Provider
+	maxDeviation float64
+	quorum       int
+	timeout      time.Duration
+}
+
+// NewMedianProvider creates a new MedianProvider of given providers. By
+// default no quote is discarded and one quote is enough.
+func NewMedianProvider(providers []Provider, opts ...MedianOption) *MedianProvider {
+	m := &MedianProvider{
+		providers: providers,
+		quorum:    1,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Rate returns the median rate of the providers.
+func (m *MedianProvider) Rate(token, currency string, timestamp time.Time) (float64, error) {
+	return m.RateContext(context.Background(), token, currency, timestamp)
+}
+
+// RateContext is like Rate but the queries are bound to given context.
+func (m *MedianProvider) RateContext(ctx context.Context, token, currency string, timestamp time.Time) (float64, error) {
+	consensus, err := m.Consensus(ctx, token, currency, timestamp)
+	if err != nil {
+		return 0, err
+	}
+	return consensus.Rate, nil
+}
+
+// Consensus is like RateContext but it returns the contributing and
+// rejected quotes as well. The returned error is a *QuorumError if
+// there are not enough accepted quotes.
+func (m *MedianProvider) Consensus(ctx context.Context, token, currency string, timestamp time.Time) (Consensus, error) {
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		quotes []Quote
+		errs   []ProviderError
+	)
+	for _, p := range m.providers {
+		p := p
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx := ctx
+			if m.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, m.timeout)
+				defer cancel()
+			}
+			rate, err := RateContext(ctx, p, token, currency, timestamp)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, ProviderError{Provider: p.Name(), Err: err})
+				return
+			}
+			quotes = append(quotes, Quote{Provider: p.Name(), Rate: rate})
+		}()
+	}
+	wg.Wait()
+
+	var consensus Consensus
+	if len(quotes) > 0 {
+		mid := median(quotes)
+		for _, q := range quotes {
+			if m.maxDeviation > 0 && math.Abs(q.Rate-mid) > math.Abs(mid)*m.maxDeviation/100 {
+				consensus.Rejected = append(consensus.Rejected, q)
+				continue
+			}
+			consensus.Sources = append(consensus.Sources, q)
+		}
+	}
+	if len(consensus.Sources) == 0 || len(consensus.Sources) < m.quorum {
+		return Consensus{}, &QuorumError{
+			Required: m.quorum,
+			Accepted: len(consensus.Sources),
+			Rejected: consensus.Rejected,
+			Errors:   errs,
+		}
+	}
+	consensus.Rate = median(consensus.Sources)
+	return consensus, nil
+}
+
+// Name return names of the providers.
+func (m *MedianProvider) Name() string {
+	return compositeName("median", m.providers)
+}
+
+// median returns the median rate of non empty quotes.
+func median(quotes []Quote) float64 {
+	rates := make([]float64, 0, len(quotes))
+	for _, q := range quotes {
+		rates = append(rates, q.Rate)
+	}
+	sort.Float64s(rates)
+	n := len(rates)
+	if n%2 == 1 {
+		return rates[n/2]
+	}
+	return (rates[n/2-1] + rates[n/2]) / 2
+}
@@ -0,0 +1,54 @@
+package tokenrate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMedianProvider(t *testing.T) {
+	providers := []Provider{
+		&fixedProvider{name: "a", rate: 100},
+		&fixedProvider{name: "b", rate: 102},
+		&fixedProvider{name: "c", rate: 101},
+		&fixedProvider{name: "bad-tick", rate: 150},
+		&fixedProvider{name: "down", err: errors.New("not available")},
+	}
+	m := NewMedianProvider(providers, WithMaxDeviation(5), WithMinQuorum(3))
+	consensus, err := m.Consensus(context.Background(), "ETH", "USD", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 101.0, consensus.Rate)
+	assert.Len(t, consensus.Sources, 3)
+	assert.Equal(t, []Quote{{Provider: "bad-tick", Rate: 150}}, consensus.Rejected)
+
+	m = NewMedianProvider(providers, WithMaxDeviation(0.5), WithMinQuorum(3))
+	_, err = m.Rate("ETH", "USD", time.Now())
+	quorumErr, ok := err.(*QuorumError)
+	require.True(t, ok)
+	assert.Equal(t, 3, quorumErr.Required)
+	assert.Equal(t, 2, quorumErr.Accepted)
+	assert.Len(t, quorumErr.Errors, 1)
+
+	m = NewMedianProvider(providers[:2])
+	rate, err := m.Rate("ETH", "USD", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 101.0, rate)
+}
+
+func TestMedianProviderTimeout(t *testing.T) {
+	m := NewMedianProvider([]Provider{
+		&fixedProvider{name: "a", rate: 100},
+		&fixedProvider{name: "hung", rate: 200, delay: time.Hour},
+		&fixedProvider{name: "b", rate: 102},
+	}, WithMinQuorum(2), WithMedianTimeout(time.Millisecond*20))
+	start := time.Now()
+	consensus, err := m.Consensus(context.Background(), "ETH", "USD", time.Now())
+	require.NoError(t, err)
+	assert.True(t, time.Since(start) < time.Millisecond*500)
+	assert.Equal(t, 101.0, consensus.Rate)
+	assert.Len(t, consensus.Sources, 2)
+}
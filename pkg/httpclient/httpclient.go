@@ -0,0 +1,221 @@
+// Package httpclient is the HTTP client configuration shared by the
+// providers querying a public HTTP API: the base URL of the API and
+// the client sending the requests, as options and CLI flags.
+package httpclient
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+// DefaultTimeout is the default timeout of each request.
+const DefaultTimeout = time.Second * 10
+
+const (
+	baseURLFlagSuffix   = "-base-url"
+	timeoutFlagSuffix   = "-timeout"
+	proxyFlagSuffix     = "-proxy"
+	userAgentFlagSuffix = "-user-agent"
+)
+
+// Config is the HTTP client configuration of a provider.
+type Config struct {
+	BaseURL string
+	Client  *http.Client
+
+	timeout   time.Duration
+	transport http.RoundTripper
+	proxy     *url.URL
+	userAgent string
+}
+
+// Option configures the HTTP client of a provider.
+type Option func(*Config)
+
+// WithBaseURL sets the base URL of the API, e.g. a local mock server.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Config) {
+		c.BaseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to send requests. The
+// timeout, transport and proxy options are ignored.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Config) {
+		c.Client = client
+	}
+}
+
+// WithTimeout sets the timeout of each request, it is ignored if
+// WithHTTPClient is given.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Config) {
+		c.timeout = timeout
+	}
+}
+
+// WithTransport sets the transport used to send requests, the proxy
+// option is ignored.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *Config) {
+		c.transport = transport
+	}
+}
+
+// WithProxy sends requests through given HTTP proxy instead of the one
+// configured in environment.
+func WithProxy(proxy *url.URL) Option {
+	return func(c *Config) {
+		c.proxy = proxy
+	}
+}
+
+// WithUserAgent sets the User-Agent header of every request.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Config) {
+		c.userAgent = userAgent
+	}
+}
+
+// NewConfig returns the configuration of given options, with given
+// base URL and a client with DefaultTimeout by default.
+func NewConfig(baseURL string, opts ...Option) Config {
+	c := Config{
+		BaseURL: baseURL,
+		timeout: DefaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if c.Client == nil {
+		transport := c.transport
+		if transport == nil && c.proxy != nil {
+			transport = newProxyTransport(c.proxy)
+		}
+		c.Client = &http.Client{
+			Transport: transport,
+			Timeout:   c.timeout,
+		}
+	}
+	if len(c.userAgent) != 0 {
+		client := *c.Client
+		client.Transport = &userAgentTransport{base: client.Transport, userAgent: c.userAgent}
+		c.Client = &client
+	}
+	return c
+}
+
+// newProxyTransport returns a transport with the settings of
+// http.DefaultTransport which sends requests through given proxy.
+func newProxyTransport(proxy *url.URL) *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyURL(proxy),
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+// userAgentTransport sets the User-Agent header of every request sent
+// through base transport.
+type userAgentTransport struct {
+	base      http.RoundTripper
+	userAgent string
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// a RoundTripper must not modify the request, send a copy
+	r := req.WithContext(req.Context())
+	r.Header = make(http.Header, len(req.Header)+1)
+	for k, v := range req.Header {
+		r.Header[k] = v
+	}
+	r.Header.Set("User-Agent", t.userAgent)
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(r)
+}
+
+// urlValue is a cli flag value of an URL, it is validated when the
+// flag is parsed.
+type urlValue struct {
+	url *url.URL
+}
+
+func (v *urlValue) Set(s string) error {
+	u, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+	v.url = u
+	return nil
+}
+
+func (v *urlValue) String() string {
+	if v.url == nil {
+		return ""
+	}
+	return v.url.String()
+}
+
+// NewFlags return cli config for the HTTP client of the provider with
+// given name, e.g. binance-base-url, binance-timeout, binance-proxy
+// and binance-user-agent. An empty baseURL leaves the default base URL
+// to the provider.
+func NewFlags(name, baseURL string) []cli.Flag {
+	envPrefix := strings.ToUpper(name)
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:   name + baseURLFlagSuffix,
+			Usage:  name + " API base URL",
+			Value:  baseURL,
+			EnvVar: envPrefix + "_BASE_URL",
+		},
+		cli.DurationFlag{
+			Name:   name + timeoutFlagSuffix,
+			Usage:  "timeout of each " + name + " API request",
+			Value:  DefaultTimeout,
+			EnvVar: envPrefix + "_TIMEOUT",
+		},
+		cli.GenericFlag{
+			Name:   name + proxyFlagSuffix,
+			Usage:  "HTTP proxy URL to reach " + name + " API, e.g: http://proxy:3128",
+			Value:  &urlValue{},
+			EnvVar: envPrefix + "_PROXY",
+		},
+		cli.StringFlag{
+			Name:   name + userAgentFlagSuffix,
+			Usage:  "User-Agent header of " + name + " API requests",
+			EnvVar: envPrefix + "_USER_AGENT",
+		},
+	}
+}
+
+// NewOptionsFromContext returns the options of the provider with given
+// name from flags created by NewFlags.
+func NewOptionsFromContext(c *cli.Context, name string) []Option {
+	opts := []Option{WithTimeout(c.Duration(name + timeoutFlagSuffix))}
+	if baseURL := c.String(name + baseURLFlagSuffix); len(baseURL) != 0 {
+		opts = append(opts, WithBaseURL(baseURL))
+	}
+	if proxy, ok := c.Generic(name + proxyFlagSuffix).(*urlValue); ok && proxy.url != nil {
+		opts = append(opts, WithProxy(proxy.url))
+	}
+	if userAgent := c.String(name + userAgentFlagSuffix); len(userAgent) != 0 {
+		opts = append(opts, WithUserAgent(userAgent))
+	}
+	return opts
+}
@@ -0,0 +1,98 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli"
+)
+
+func TestNewConfig(t *testing.T) {
+	c := NewConfig("https://api.example.com")
+	assert.Equal(t, "https://api.example.com", c.BaseURL)
+	assert.Equal(t, DefaultTimeout, c.Client.Timeout)
+
+	client := &http.Client{}
+	c = NewConfig("https://api.example.com", WithBaseURL("http://127.0.0.1:8080/"), WithHTTPClient(client), WithTimeout(time.Second))
+	assert.Equal(t, "http://127.0.0.1:8080", c.BaseURL)
+	assert.Equal(t, client, c.Client)
+
+	proxy, err := url.Parse("http://proxy:3128")
+	require.NoError(t, err)
+	c = NewConfig("https://api.example.com", WithProxy(proxy), WithTimeout(time.Second))
+	assert.Equal(t, time.Second, c.Client.Timeout)
+	transport, ok := c.Client.Transport.(*http.Transport)
+	require.True(t, ok)
+	proxyURL, err := transport.Proxy(&http.Request{})
+	require.NoError(t, err)
+	assert.Equal(t, proxy, proxyURL)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestUserAgent(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "tokenrate-test", r.Header.Get("User-Agent"))
+	}))
+	defer ts.Close()
+
+	c := NewConfig(ts.URL, WithUserAgent("tokenrate-test"))
+	req, err := http.NewRequest(http.MethodGet, c.BaseURL, nil)
+	require.NoError(t, err)
+	rsp, err := c.Client.Do(req)
+	require.NoError(t, err)
+	_ = rsp.Body.Close()
+	assert.Empty(t, req.Header.Get("User-Agent"), "request of the caller must not be modified")
+
+	// the user agent is set on top of the given transport
+	var userAgent string
+	c = NewConfig(ts.URL, WithUserAgent("tokenrate-test"), WithTransport(roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		userAgent = r.Header.Get("User-Agent")
+		return nil, errors.New("unreachable")
+	})))
+	_, err = c.Client.Get(c.BaseURL)
+	require.Error(t, err)
+	assert.Equal(t, "tokenrate-test", userAgent)
+}
+
+func TestFlags(t *testing.T) {
+	require.NoError(t, os.Setenv("EXAMPLE_TIMEOUT", "3s"))
+	defer func() {
+		_ = os.Unsetenv("EXAMPLE_TIMEOUT")
+	}()
+
+	a := cli.NewApp()
+	a.Flags = NewFlags("example", "https://api.example.com")
+	a.Action = func(ctx *cli.Context) error {
+		c := NewConfig("", NewOptionsFromContext(ctx, "example")...)
+		assert.Equal(t, "http://127.0.0.1:8080", c.BaseURL)
+		assert.Equal(t, 3*time.Second, c.Client.Timeout)
+		return nil
+	}
+	require.NoError(t, a.Run([]string{"test", "--example-base-url", "http://127.0.0.1:8080/"}))
+}
+
+func TestProxyAndUserAgentFlags(t *testing.T) {
+	a := cli.NewApp()
+	a.Flags = NewFlags("example", "")
+	a.Action = func(ctx *cli.Context) error {
+		c := NewConfig("https://api.example.com", NewOptionsFromContext(ctx, "example")...)
+		assert.Equal(t, "https://api.example.com", c.BaseURL)
+		assert.Equal(t, "tokenrate-test", c.userAgent)
+		assert.Equal(t, "proxy:3128", c.proxy.Host)
+		return nil
+	}
+	require.NoError(t, a.Run([]string{"test", "--example-proxy", "http://proxy:3128", "--example-user-agent", "tokenrate-test"}))
+	assert.Error(t, a.Run([]string{"test", "--example-proxy", "http://[::1"}))
+}
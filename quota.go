@@ -0,0 +1,20 @@
+package tokenrate
+
+import "time"
+
+// Quota is the API usage quota of a provider as reported by the
+// provider API.
+type Quota struct {
+	// Remaining is the number of requests left in current window.
+	Remaining int
+	// Reset is the time current window ends, zero if unknown.
+	Reset time.Time
+}
+
+// QuotaReporter is the optional interface implemented by providers
+// that know their remaining API quota.
+type QuotaReporter interface {
+	// Quota returns the last known quota, false if it is not known
+	// yet.
+	Quota() (Quota, bool)
+}
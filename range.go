@@ -0,0 +1,54 @@
+package tokenrate
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// RatePoint is a rate sample of a time series.
+type RatePoint struct {
+	Timestamp time.Time
+	Rate      float64
+}
+
+// RangeProvider is the optional interface implemented by providers
+// that are able to return the time series of rates between two
+// timestamps in one go. The returned points are sorted by timestamp,
+// the sampling interval is decided by the provider.
+type RangeProvider interface {
+	RateRange(ctx context.Context, token, currency string, from, to time.Time) ([]RatePoint, error)
+	// Name return name of provider
+	Name() string
+}
+
+// ETHUSDRangeProvider is the optional interface implemented by
+// ETHUSDRateProvider that are able to return the time series of ETH/USD
+// rates between two timestamps in one go.
+type ETHUSDRangeProvider interface {
+	USDRateRange(ctx context.Context, from, to time.Time) ([]RatePoint, error)
+	// Name return name of provider
+	Name() string
+}
+
+// NearestPoint returns the point of sorted points which is nearest to
+// given timestamp. It returns false if points is empty.
+func NearestPoint(points []RatePoint, timestamp time.Time) (RatePoint, bool) {
+	if len(points) == 0 {
+		return RatePoint{}, false
+	}
+	i := sort.Search(len(points), func(i int) bool {
+		return !points[i].Timestamp.Before(timestamp)
+	})
+	switch {
+	case i == 0:
+		return points[0], true
+	case i == len(points):
+		return points[len(points)-1], true
+	}
+	before, after := points[i-1], points[i]
+	if timestamp.Sub(before.Timestamp) <= after.Timestamp.Sub(timestamp) {
+		return before, true
+	}
+	return after, true
+}
@@ -0,0 +1,35 @@
+package tokenrate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNearestPoint(t *testing.T) {
+	_, ok := NearestPoint(nil, time.Now())
+	assert.False(t, ok)
+
+	day := time.Date(2019, 10, 11, 0, 0, 0, 0, time.UTC)
+	points := []RatePoint{
+		{Timestamp: day.Add(-time.Hour), Rate: 1},
+		{Timestamp: day.Add(2 * time.Hour), Rate: 2},
+		{Timestamp: day.Add(3 * time.Hour), Rate: 3},
+	}
+	var tests = []struct {
+		timestamp time.Time
+		rate      float64
+	}{
+		{timestamp: day.Add(-2 * time.Hour), rate: 1},
+		{timestamp: day, rate: 1},
+		{timestamp: day.Add(time.Hour + time.Minute), rate: 2},
+		{timestamp: day.Add(3 * time.Hour), rate: 3},
+		{timestamp: day.Add(24 * time.Hour), rate: 3},
+	}
+	for _, tc := range tests {
+		point, ok := NearestPoint(points, tc.timestamp)
+		assert.True(t, ok)
+		assert.Equal(t, tc.rate, point.Rate, "timestamp: %s", tc.timestamp)
+	}
+}
@@ -0,0 +1,114 @@
+package ratelimit
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli"
+
+	"github.com/KyberNetwork/tokenrate"
+	"github.com/KyberNetwork/tokenrate/common"
+)
+
+const (
+	rateLimitFlagSuffix = "-requests-per-minute"
+	burstFlagSuffix     = "-burst"
+)
+
+// budget is the default request budget of a provider.
+type budget struct {
+	perMinute float64
+	burst     int
+}
+
+var defaultBudgets = map[string]budget{
+	common.Coingecko:     {perMinute: 50, burst: 5},
+	common.CoinLib:       {perMinute: 3, burst: 1},    // coinlib rate limit is 180/hour
+	common.Binance:       {perMinute: 600, burst: 10}, // binance request weight limit is 1200/minute
+	common.Coinbase:      {perMinute: 300, burst: 5},  // coinbase public rate limit is 10/second
+	common.CryptoCompare: {perMinute: 60, burst: 5},
+	common.CoinMarketCap: {perMinute: 30, burst: 1}, // coinmarketcap basic plan rate limit is 30/minute
+	common.Kraken:        {perMinute: 60, burst: 1}, // kraken public rate limit is 1/second
+}
+
+// NewFlags return cli config for the request budget of each provider.
+func NewFlags() []cli.Flag {
+	var flags []cli.Flag
+	for _, name := range providerNames() {
+		b := defaultBudgets[name]
+		flags = append(flags,
+			cli.Float64Flag{
+				Name:   name + rateLimitFlagSuffix,
+				Usage:  "maximum average requests per minute to " + name + ", 0 means unlimited",
+				Value:  b.perMinute,
+				EnvVar: envVar(name + rateLimitFlagSuffix),
+			},
+			cli.IntFlag{
+				Name:   name + burstFlagSuffix,
+				Usage:  "maximum burst requests to " + name,
+				Value:  b.burst,
+				EnvVar: envVar(name + burstFlagSuffix),
+			},
+		)
+	}
+	return flags
+}
+
+// Limiters holds the Limiter of each provider by name.
+type Limiters map[string]*Limiter
+
+// NewLimitersFromContext return the limiters configured by cli flags.
+func NewLimitersFromContext(c *cli.Context) Limiters {
+	limiters := make(Limiters)
+	for _, name := range providerNames() {
+		limiters[name] = NewLimiter(c.Float64(name+rateLimitFlagSuffix)/60, c.Int(name+burstFlagSuffix))
+	}
+	return limiters
+}
+
+// Wrap returns the rate limited provider of p, or p itself if there is
+// no limiter for it.
+func (ls Limiters) Wrap(p tokenrate.Provider) tokenrate.Provider {
+	l, ok := ls[p.Name()]
+	if !ok {
+		return p
+	}
+	return New(p, l)
+}
+
+// WrapETHUSD returns the rate limited provider of p, or p itself if
+// there is no limiter for it.
+func (ls Limiters) WrapETHUSD(p tokenrate.ETHUSDRateProvider) tokenrate.ETHUSDRateProvider {
+	l, ok := ls[p.Name()]
+	if !ok {
+		return p
+	}
+	return NewETHUSDRateProvider(p, l)
+}
+
+// envVar returns the environment variable of given flag, e.g.
+// COINGECKO_REQUESTS_PER_MINUTE for coingecko-requests-per-minute.
+func envVar(flag string) string {
+	return strings.ToUpper(strings.Replace(flag, "-", "_", -1))
+}
+
+func providerNames() []string {
+	names := make([]string, 0, len(defaultBudgets))
+	for name := range defaultBudgets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Wait blocks until a request to provider with given name is allowed
+// or ctx is done. It is for queries that are not made through a
+// wrapped provider, e.g range queries.
+func (ls Limiters) Wait(ctx context.Context, name string) error {
+	l, ok := ls[name]
+	if !ok {
+		return nil
+	}
+	return l.Wait(ctx)
+}
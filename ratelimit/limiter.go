@@ -0,0 +1,88 @@
+// Package ratelimit provides a client side rate limiting wrapper for
+// rate providers, so the queries stay within the provider API budget.
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket limiting the rate of requests to a
+// provider. A Limiter is safe for concurrent use and should be shared
+// by all queries to the same provider.
+type Limiter struct {
+	mu          sync.Mutex
+	perSecond   float64
+	burst       float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+}
+
+// NewLimiter creates a new Limiter allowing perSecond requests per
+// second on average with bursts of at most burst requests. A non
+// positive perSecond means unlimited.
+func NewLimiter(perSecond float64, burst int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{
+		perSecond: perSecond,
+		burst:     float64(burst),
+		tokens:    float64(burst),
+		last:      time.Now(),
+	}
+}
+
+// Wait blocks until a request is allowed or ctx is done.
+func (l *Limiter) Wait(ctx context.Context) error {
+	for {
+		delay := l.reserve(time.Now())
+		if delay <= 0 {
+			return nil
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// PauseUntil blocks all requests until given time, e.g when the
+// provider asks to retry after some time.
+func (l *Limiter) PauseUntil(t time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if t.After(l.pausedUntil) {
+		l.pausedUntil = t
+	}
+}
+
+// reserve takes a token from the bucket, it returns the duration to
+// wait if no token is available.
+func (l *Limiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Before(l.pausedUntil) {
+		return l.pausedUntil.Sub(now)
+	}
+	if l.perSecond <= 0 {
+		return 0
+	}
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens += elapsed.Seconds() * l.perSecond
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / l.perSecond * float64(time.Second))
+}
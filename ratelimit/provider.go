@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/KyberNetwork/tokenrate"
+	"github.com/KyberNetwork/tokenrate/common"
+)
+
+const (
+	// defaultRetryAfter is the pause when the provider rejects a query
+	// for rate limit without telling when to retry.
+	defaultRetryAfter = time.Minute
+	// defaultQuotaPause is the pause when the provider reports its
+	// quota is exhausted without telling when it is reset.
+	defaultQuotaPause = time.Minute
+)
+
+// CacheReporter is the optional interface implemented by providers
+// that answer some queries from their own cache. Such queries make no
+// upstream request, so they are not rate limited.
+type CacheReporter interface {
+	// Cached returns true if the query would be answered from cache.
+	Cached(token, currency string, timestamp time.Time) bool
+}
+
+// Provider is a tokenrate.Provider limiting the rate of queries to the
+// wrapped provider.
+type Provider struct {
+	p      tokenrate.Provider
+	quota  tokenrate.QuotaReporter
+	cached CacheReporter
+	l      *Limiter
+}
+
+// New creates a rate limited Provider of p with given limiter.
+func New(p tokenrate.Provider, l *Limiter) *Provider {
+	quota, _ := p.(tokenrate.QuotaReporter)
+	cached, _ := p.(CacheReporter)
+	return &Provider{p: p, quota: quota, cached: cached, l: l}
+}
+
+// NewETHUSDRateProvider creates a rate limited ETHUSDRateProvider of p.
+func NewETHUSDRateProvider(p tokenrate.ETHUSDRateProvider, l *Limiter) tokenrate.ETHUSDRateProviderContext {
+	quota, _ := p.(tokenrate.QuotaReporter)
+	cached, _ := p.(CacheReporter)
+	rp := &Provider{p: tokenrate.FromETHUSDRateProvider(p), quota: quota, cached: cached, l: l}
+	return tokenrate.ToETHUSDRateProvider(rp, common.ETHID, common.USDID)
+}
+
+// Rate waits for the rate limit then queries the wrapped provider.
+func (r *Provider) Rate(token, currency string, timestamp time.Time) (float64, error) {
+	return r.RateContext(context.Background(), token, currency, timestamp)
+}
+
+// RateContext is like Rate but waiting and query are bound to given
+// context. Queries answered from the provider cache do not wait.
+func (r *Provider) RateContext(ctx context.Context, token, currency string, timestamp time.Time) (float64, error) {
+	if r.cached != nil && r.cached.Cached(token, currency, timestamp) {
+		return tokenrate.RateContext(ctx, r.p, token, currency, timestamp)
+	}
+	if err := r.l.Wait(ctx); err != nil {
+		return 0, err
+	}
+	rate, err := tokenrate.RateContext(ctx, r.p, token, currency, timestamp)
+	if retryAfter, ok := tokenrate.RetryAfter(err); ok {
+		if retryAfter <= 0 {
+			retryAfter = defaultRetryAfter
+		}
+		r.l.PauseUntil(time.Now().Add(retryAfter))
+	}
+	if r.quota != nil {
+		if q, ok := r.quota.Quota(); ok && q.Remaining <= 0 {
+			reset := q.Reset
+			if reset.IsZero() {
+				reset = time.Now().Add(defaultQuotaPause)
+			}
+			r.l.PauseUntil(reset)
+		}
+	}
+	return rate, err
+}
+
+// Name return name of the wrapped provider.
+func (r *Provider) Name() string {
+	return r.p.Name()
+}
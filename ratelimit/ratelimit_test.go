@@ -0,0 +1,125 @@
+package ratelimit
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli"
+
+	"github.com/KyberNetwork/tokenrate"
+)
+
+type limitedProvider struct {
+	calls     int
+	remaining int
+}
+
+func (l *limitedProvider) Rate(token, currency string, timestamp time.Time) (float64, error) {
+	l.calls++
+	if l.calls == 1 {
+		return 0, &tokenrate.ErrRateLimited{RetryAfter: time.Millisecond * 100}
+	}
+	return 1, nil
+}
+
+func (l *limitedProvider) Name() string {
+	return "limited"
+}
+
+func (l *limitedProvider) Quota() (tokenrate.Quota, bool) {
+	return tokenrate.Quota{Remaining: l.remaining}, true
+}
+
+func TestLimiter(t *testing.T) {
+	l := NewLimiter(100, 2)
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		require.NoError(t, l.Wait(context.Background()))
+	}
+	// 2 burst requests and 2 at 10ms interval
+	elapsed := time.Since(start)
+	assert.True(t, elapsed >= time.Millisecond*15, "elapsed: %s", elapsed)
+
+	l = NewLimiter(1.0/60, 1)
+	require.NoError(t, l.Wait(context.Background()))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, l.Wait(ctx))
+}
+
+func TestProviderRetryAfter(t *testing.T) {
+	var (
+		p = &limitedProvider{remaining: 10}
+		r = New(p, NewLimiter(0, 1))
+	)
+	_, err := r.Rate("ETH", "USD", time.Now())
+	_, ok := tokenrate.RetryAfter(err)
+	require.True(t, ok)
+
+	start := time.Now()
+	rate, err := r.Rate("ETH", "USD", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, rate)
+	assert.True(t, time.Since(start) >= time.Millisecond*90, "limiter should pause until retry after")
+}
+
+func TestProviderQuotaExhausted(t *testing.T) {
+	var (
+		p = &limitedProvider{calls: 1}
+		r = New(p, NewLimiter(0, 1))
+	)
+	_, err := r.Rate("ETH", "USD", time.Now())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	_, err = r.RateContext(ctx, "ETH", "USD", time.Now())
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, 2, p.calls)
+}
+
+type cachedProvider struct {
+	limitedProvider
+}
+
+func (c *cachedProvider) Cached(token, currency string, timestamp time.Time) bool {
+	return c.calls > 0
+}
+
+func TestProviderSkipsCached(t *testing.T) {
+	var (
+		p = &cachedProvider{limitedProvider{calls: 1}}
+		r = New(p, NewLimiter(1.0/60, 1))
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	for i := 0; i < 3; i++ {
+		rate, err := r.RateContext(ctx, "ETH", "USD", time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, 1.0, rate)
+	}
+	assert.Equal(t, 4, p.calls)
+}
+
+func TestFlagsEnvVar(t *testing.T) {
+	require.NoError(t, os.Setenv("COINGECKO_REQUESTS_PER_MINUTE", "120"))
+	require.NoError(t, os.Setenv("COINGECKO_BURST", "7"))
+	defer func() {
+		_ = os.Unsetenv("COINGECKO_REQUESTS_PER_MINUTE")
+		_ = os.Unsetenv("COINGECKO_BURST")
+	}()
+
+	a := cli.NewApp()
+	a.Flags = NewFlags()
+	a.Action = func(c *cli.Context) error {
+		assert.Equal(t, 120.0, c.Float64("coingecko-requests-per-minute"))
+		assert.Equal(t, 7, c.Int("coingecko-burst"))
+		assert.Equal(t, 3.0, c.Float64("coinlib-requests-per-minute"))
+		return nil
+	}
+	require.NoError(t, a.Run([]string{"test"}))
+}
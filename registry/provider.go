@@ -0,0 +1,37 @@
+package registry
+
+import (
+	"context"
+	"time"
+
+	"github.com/KyberNetwork/tokenrate"
+)
+
+// Provider wraps a tokenrate.Provider to translate canonical token and
+// currency symbols to the IDs the wrapped provider understands.
+type Provider struct {
+	p tokenrate.Provider
+	r *Registry
+}
+
+// Wrap returns a Provider normalizing queries to p with given registry.
+func Wrap(p tokenrate.Provider, r *Registry) *Provider {
+	return &Provider{p: p, r: r}
+}
+
+// Rate returns the rate of given canonical token in given canonical
+// currency at given timestamp.
+func (w *Provider) Rate(token, currency string, timestamp time.Time) (float64, error) {
+	return w.RateContext(context.Background(), token, currency, timestamp)
+}
+
+// RateContext is like Rate but the query is bound to given context.
+func (w *Provider) RateContext(ctx context.Context, token, currency string, timestamp time.Time) (float64, error) {
+	name := w.p.Name()
+	return tokenrate.RateContext(ctx, w.p, w.r.TokenID(name, token), w.r.CurrencyID(name, currency), timestamp)
+}
+
+// Name return name of the wrapped provider.
+func (w *Provider) Name() string {
+	return w.p.Name()
+}
@@ -0,0 +1,174 @@
+// Package registry maps canonical token and currency identities to the
+// IDs each provider uses for them, so the same query works with every
+// provider.
+package registry
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/KyberNetwork/tokenrate/common"
+)
+
+const (
+	// ChainEthereum is the chain name of Ethereum mainnet.
+	ChainEthereum = "ethereum"
+	// ChainBitcoin is the chain name of Bitcoin.
+	ChainBitcoin = "bitcoin"
+)
+
+// Token is the canonical identity of a token.
+type Token struct {
+	Symbol string
+	// Chain is the name of the chain the token lives on, e.g ethereum.
+	Chain string
+	// Address is the contract address of the token, empty for native
+	// assets of the chain.
+	Address string
+}
+
+// Registry maps canonical tokens and currencies to provider specific
+// IDs. A Registry is safe for concurrent use.
+type Registry struct {
+	mu          sync.RWMutex
+	tokens      map[string]Token
+	addresses   map[string]Token
+	tokenIDs    map[string]map[string]string
+	currencyIDs map[string]map[string]string
+}
+
+// New creates an empty Registry.
+func New() *Registry {
+	return &Registry{
+		tokens:      make(map[string]Token),
+		addresses:   make(map[string]Token),
+		tokenIDs:    make(map[string]map[string]string),
+		currencyIDs: make(map[string]map[string]string),
+	}
+}
+
+// Register adds given token with its IDs keyed by provider name. A
+// token registered later with the same symbol replaces the former one
+// in symbol lookups.
+func (r *Registry) Register(token Token, ids map[string]string) {
+	token.Symbol = normalizeSymbol(token.Symbol)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens[token.Symbol] = token
+	if len(token.Address) != 0 {
+		r.addresses[addressKey(token.Chain, token.Address)] = token
+	}
+	for provider, id := range ids {
+		if r.tokenIDs[provider] == nil {
+			r.tokenIDs[provider] = make(map[string]string)
+		}
+		r.tokenIDs[provider][token.Symbol] = id
+	}
+}
+
+// RegisterCurrency adds given currency with its IDs keyed by provider
+// name.
+func (r *Registry) RegisterCurrency(currency string, ids map[string]string) {
+	currency = normalizeSymbol(currency)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for provider, id := range ids {
+		if r.currencyIDs[provider] == nil {
+			r.currencyIDs[provider] = make(map[string]string)
+		}
+		r.currencyIDs[provider][currency] = id
+	}
+}
+
+// Lookup returns the token registered with given symbol.
+func (r *Registry) Lookup(symbol string) (Token, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	token, ok := r.tokens[normalizeSymbol(symbol)]
+	return token, ok
+}
+
+// LookupAddress returns the token registered with given contract
+// address on given chain.
+func (r *Registry) LookupAddress(chain, address string) (Token, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	token, ok := r.addresses[addressKey(chain, address)]
+	return token, ok
+}
+
+// TokenID returns the ID of token with given symbol for given provider.
+// The symbol is returned unchanged if there is no mapping, so provider
+// specific IDs still work.
+func (r *Registry) TokenID(provider, symbol string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if id, ok := r.tokenIDs[provider][normalizeSymbol(symbol)]; ok {
+		return id
+	}
+	return symbol
+}
+
+// CurrencyID returns the ID of given currency for given provider. The
+// currency is returned unchanged if there is no mapping.
+func (r *Registry) CurrencyID(provider, currency string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if id, ok := r.currencyIDs[provider][normalizeSymbol(currency)]; ok {
+		return id
+	}
+	return currency
+}
+
+func normalizeSymbol(symbol string) string {
+	return strings.ToUpper(symbol)
+}
+
+func addressKey(chain, address string) string {
+	return chain + ":" + strings.ToLower(address)
+}
+
+// symbolProviders are the providers identifying tokens and currencies
+// by their symbols.
+var symbolProviders = []string{common.CoinLib, common.Binance, common.Coinbase, common.CryptoCompare, common.CoinMarketCap, common.Kraken}
+
+// symbolIDs returns the IDs of given symbol for the symbol providers
+// merged with given IDs of the other providers.
+func symbolIDs(symbol string, ids map[string]string) map[string]string {
+	result := make(map[string]string, len(symbolProviders)+len(ids))
+	for _, provider := range symbolProviders {
+		result[provider] = symbol
+	}
+	for provider, id := range ids {
+		result[provider] = id
+	}
+	return result
+}
+
+// Default returns a new Registry pre-loaded with commonly used tokens
+// and currencies of the providers of this library.
+func Default() *Registry {
+	r := New()
+	r.Register(Token{Symbol: common.ETHID, Chain: ChainEthereum, Address: "0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE"},
+		symbolIDs(common.ETHID, map[string]string{common.Coingecko: "ethereum"}))
+	r.Register(Token{Symbol: "BTC", Chain: ChainBitcoin},
+		symbolIDs("BTC", map[string]string{common.Coingecko: "bitcoin", common.Kraken: "XBT"}))
+	r.Register(Token{Symbol: "KNC", Chain: ChainEthereum, Address: "0xdd974D5C2e2928deA5F71b9825b8b646686BD200"},
+		symbolIDs("KNC", map[string]string{common.Coingecko: "kyber-network"}))
+	r.Register(Token{Symbol: "DAI", Chain: ChainEthereum, Address: "0x6B175474E89094C44Da98b954EedeAC495271d0F"},
+		symbolIDs("DAI", map[string]string{common.Coingecko: "dai"}))
+	r.Register(Token{Symbol: "USDC", Chain: ChainEthereum, Address: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"},
+		symbolIDs("USDC", map[string]string{common.Coingecko: "usd-coin"}))
+	r.Register(Token{Symbol: "USDT", Chain: ChainEthereum, Address: "0xdAC17F958D2ee523a2206206994597C13D831ec7"},
+		symbolIDs("USDT", map[string]string{common.Coingecko: "tether"}))
+
+	for _, currency := range []string{common.USDID, "EUR", "SGD", common.ETHID, "BTC"} {
+		r.RegisterCurrency(currency, symbolIDs(currency, map[string]string{
+			common.Coingecko: strings.ToLower(currency),
+		}))
+	}
+	// binance has no USD market, USD is quoted in USDT
+	r.RegisterCurrency(common.USDID, map[string]string{common.Binance: "USDT"})
+	r.RegisterCurrency("BTC", map[string]string{common.Kraken: "XBT"})
+	return r
+}
@@ -0,0 +1,75 @@
+package registry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/tokenrate/common"
+)
+
+type recordProvider struct {
+	name            string
+	token, currency string
+}
+
+func (r *recordProvider) Rate(token, currency string, timestamp time.Time) (float64, error) {
+	r.token, r.currency = token, currency
+	return 1, nil
+}
+
+func (r *recordProvider) Name() string {
+	return r.name
+}
+
+func TestRegistryLookup(t *testing.T) {
+	r := Default()
+	token, ok := r.Lookup("knc")
+	require.True(t, ok)
+	assert.Equal(t, ChainEthereum, token.Chain)
+
+	token, ok = r.LookupAddress(ChainEthereum, "0xDD974D5C2E2928DEA5F71B9825B8B646686BD200")
+	require.True(t, ok)
+	assert.Equal(t, "KNC", token.Symbol)
+
+	_, ok = r.Lookup("UNKNOWN")
+	assert.False(t, ok)
+}
+
+func TestWrap(t *testing.T) {
+	var tests = []struct {
+		provider string
+		token    string
+		currency string
+	}{
+		{provider: common.Coingecko, token: "kyber-network", currency: "usd"},
+		{provider: common.CoinLib, token: "KNC", currency: "USD"},
+		{provider: common.Binance, token: "KNC", currency: "USDT"},
+		{provider: common.Coinbase, token: "KNC", currency: "USD"},
+		{provider: common.CryptoCompare, token: "KNC", currency: "USD"},
+		{provider: common.CoinMarketCap, token: "KNC", currency: "USD"},
+		{provider: common.Kraken, token: "KNC", currency: "USD"},
+	}
+	r := Default()
+	for _, tc := range tests {
+		p := &recordProvider{name: tc.provider}
+		_, err := Wrap(p, r).Rate("KNC", "USD", time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, tc.token, p.token)
+		assert.Equal(t, tc.currency, p.currency)
+	}
+
+	p := &recordProvider{name: common.Kraken}
+	_, err := Wrap(p, r).Rate("BTC", "EUR", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "XBT", p.token)
+
+	// unknown tokens are passed through
+	p = &recordProvider{name: common.Coingecko}
+	_, err = Wrap(p, r).Rate("bitcoin-cash", "usd", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "bitcoin-cash", p.token)
+	assert.Equal(t, "usd", p.currency)
+}
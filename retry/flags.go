@@ -0,0 +1,53 @@
+package retry
+
+import (
+	"github.com/urfave/cli"
+)
+
+const (
+	maxAttemptsFlag    = "retry-max-attempts"
+	initialBackoffFlag = "retry-initial-backoff"
+	maxBackoffFlag     = "retry-max-backoff"
+	jitterFlag         = "retry-jitter"
+)
+
+// NewFlags return cli config for retrying provider queries.
+func NewFlags() []cli.Flag {
+	opts := DefaultOptions()
+	return []cli.Flag{
+		cli.IntFlag{
+			Name:   maxAttemptsFlag,
+			Usage:  "maximum attempts of a provider query, 1 disables retry",
+			EnvVar: "RETRY_MAX_ATTEMPTS",
+			Value:  opts.MaxAttempts,
+		},
+		cli.DurationFlag{
+			Name:   initialBackoffFlag,
+			Usage:  "wait before the first retry, doubled after each attempt",
+			EnvVar: "RETRY_INITIAL_BACKOFF",
+			Value:  opts.InitialBackoff,
+		},
+		cli.DurationFlag{
+			Name:   maxBackoffFlag,
+			Usage:  "maximum wait between attempts",
+			EnvVar: "RETRY_MAX_BACKOFF",
+			Value:  opts.MaxBackoff,
+		},
+		cli.Float64Flag{
+			Name:   jitterFlag,
+			Usage:  "fraction of the wait to randomize, from 0 to 1",
+			EnvVar: "RETRY_JITTER",
+			Value:  opts.Jitter,
+		},
+	}
+}
+
+// NewOptionsFromContext return retry options configured by cli flags.
+func NewOptionsFromContext(c *cli.Context) Options {
+	opts := DefaultOptions()
+	opts.MaxAttempts = c.Int(maxAttemptsFlag)
+	opts.InitialBackoff = c.Duration(initialBackoffFlag)
+	opts.MaxBackoff = c.Duration(maxBackoffFlag)
+	opts.Jitter = c.Float64(jitterFlag)
+	return opts
+}
@@ -0,0 +1,48 @@
+package retry
+
+import (
+	"context"
+	"time"
+
+	"github.com/KyberNetwork/tokenrate"
+	"github.com/KyberNetwork/tokenrate/common"
+)
+
+// Provider is a tokenrate.Provider retrying the failed queries of the
+// wrapped provider.
+type Provider struct {
+	p    tokenrate.Provider
+	opts Options
+}
+
+// New creates a retrying Provider of p.
+func New(p tokenrate.Provider, opts Options) *Provider {
+	return &Provider{p: p, opts: opts}
+}
+
+// NewETHUSDRateProvider creates a retrying ETHUSDRateProvider of p.
+func NewETHUSDRateProvider(p tokenrate.ETHUSDRateProvider, opts Options) tokenrate.ETHUSDRateProviderContext {
+	return tokenrate.ToETHUSDRateProvider(New(tokenrate.FromETHUSDRateProvider(p), opts), common.ETHID, common.USDID)
+}
+
+// Rate queries the wrapped provider, retrying on transient failures.
+func (r *Provider) Rate(token, currency string, timestamp time.Time) (float64, error) {
+	return r.RateContext(context.Background(), token, currency, timestamp)
+}
+
+// RateContext is like Rate but the attempts and waits are bound to
+// given context.
+func (r *Provider) RateContext(ctx context.Context, token, currency string, timestamp time.Time) (float64, error) {
+	var rate float64
+	err := Do(ctx, r.opts, func(ctx context.Context) error {
+		var err error
+		rate, err = tokenrate.RateContext(ctx, r.p, token, currency, timestamp)
+		return err
+	})
+	return rate, err
+}
+
+// Name return name of the wrapped provider.
+func (r *Provider) Name() string {
+	return r.p.Name()
+}
@@ -0,0 +1,92 @@
+// Package retry provides a wrapper for rate providers retrying
+// transient failures with exponential backoff and jitter.
+package retry
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/KyberNetwork/tokenrate"
+)
+
+// Options configures the retry behaviour.
+type Options struct {
+	// MaxAttempts is the maximum number of attempts including the
+	// first one.
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between attempts.
+	MaxBackoff time.Duration
+	// Multiplier is the growth factor of backoff after each attempt.
+	Multiplier float64
+	// Jitter is the fraction in [0, 1] of backoff to randomize, so
+	// concurrent clients do not retry in lockstep.
+	Jitter float64
+	// Retryable reports whether a failed attempt is worth retrying.
+	Retryable func(error) bool
+}
+
+// DefaultOptions returns the default Options, which only retry
+// network errors, upstream server errors and rate limit.
+func DefaultOptions() Options {
+	return Options{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond * 500,
+		MaxBackoff:     time.Second * 10,
+		Multiplier:     2,
+		Jitter:         0.2,
+		Retryable:      tokenrate.IsRetryable,
+	}
+}
+
+// Do calls fn until it succeeds, fails with a non retryable error or
+// the attempts are exhausted. The last error is returned. If the error
+// tells to retry after some time, the wait is at least that long.
+func Do(ctx context.Context, opts Options, fn func(ctx context.Context) error) error {
+	var (
+		backoff   = opts.InitialBackoff
+		retryable = opts.Retryable
+	)
+	if retryable == nil {
+		retryable = tokenrate.IsRetryable
+	}
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || attempt >= opts.MaxAttempts || !retryable(err) {
+			return err
+		}
+
+		wait := jitter(backoff, opts.Jitter)
+		if retryAfter, ok := tokenrate.RetryAfter(err); ok && retryAfter > wait {
+			wait = retryAfter
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		if opts.Multiplier > 1 {
+			backoff = time.Duration(float64(backoff) * opts.Multiplier)
+		}
+		if opts.MaxBackoff > 0 && backoff > opts.MaxBackoff {
+			backoff = opts.MaxBackoff
+		}
+	}
+}
+
+// jitter randomizes given fraction of d.
+func jitter(d time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 || d <= 0 {
+		return d
+	}
+	if fraction > 1 {
+		fraction = 1
+	}
+	delta := float64(d) * fraction
+	return time.Duration(float64(d) - delta + rand.Float64()*2*delta)
+}
@@ -0,0 +1,78 @@
+package retry
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/tokenrate"
+)
+
+type flakyProvider struct {
+	calls    int
+	failures int
+	err      error
+}
+
+func (f *flakyProvider) Rate(token, currency string, timestamp time.Time) (float64, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return 0, f.err
+	}
+	return 100, nil
+}
+
+func (f *flakyProvider) Name() string {
+	return "flaky"
+}
+
+func testOptions() Options {
+	opts := DefaultOptions()
+	opts.InitialBackoff = time.Millisecond
+	opts.MaxBackoff = time.Millisecond * 5
+	return opts
+}
+
+func TestRetryTransient(t *testing.T) {
+	p := &flakyProvider{failures: 2, err: &tokenrate.ErrUpstream{StatusCode: http.StatusBadGateway, Status: "502 Bad Gateway"}}
+	rate, err := New(p, testOptions()).Rate("ETH", "USD", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 100.0, rate)
+	assert.Equal(t, 3, p.calls)
+
+	p = &flakyProvider{failures: 3, err: p.err}
+	_, err = New(p, testOptions()).Rate("ETH", "USD", time.Now())
+	assert.Error(t, err)
+	assert.Equal(t, 3, p.calls)
+}
+
+func TestRetryNotRetryable(t *testing.T) {
+	p := &flakyProvider{failures: 1, err: errors.Wrap(tokenrate.ErrUnsupportedToken, "unknown")}
+	_, err := New(p, testOptions()).Rate("ETH", "USD", time.Now())
+	assert.Equal(t, tokenrate.ErrUnsupportedToken, errors.Cause(err))
+	assert.Equal(t, 1, p.calls)
+}
+
+func TestRetryContextCancelled(t *testing.T) {
+	opts := testOptions()
+	opts.InitialBackoff = time.Minute
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	p := &flakyProvider{failures: 1, err: &tokenrate.ErrRateLimited{}}
+	_, err := New(p, opts).RateContext(ctx, "ETH", "USD", time.Now())
+	assert.Error(t, err)
+	assert.Equal(t, 1, p.calls)
+}
+
+func TestJitter(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		d := jitter(time.Second, 0.2)
+		assert.True(t, d >= time.Millisecond*800 && d <= time.Millisecond*1200, "jitter: %s", d)
+	}
+	assert.Equal(t, time.Second, jitter(time.Second, 0))
+}
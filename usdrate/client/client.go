@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/pkg/errors"
 
+	"github.com/KyberNetwork/tokenrate"
 	"github.com/KyberNetwork/tokenrate/common"
 )
 
@@ -28,8 +30,17 @@ func New(c *http.Client, baseURL string) *Client {
 
 // USDRate ...
 func (c *Client) USDRate(timestamp time.Time) (float64, error) {
+	return c.USDRateContext(context.Background(), timestamp)
+}
+
+// USDRateContext is like USDRate but the request is bound to given context.
+func (c *Client) USDRateContext(ctx context.Context, timestamp time.Time) (float64, error) {
 	url := c.baseURL + "/price/eth-usd"
-	resp, err := c.c.Get(url)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "make usd rate request")
+	}
+	resp, err := c.c.Do(req.WithContext(ctx))
 	if err != nil {
 		return 0, errors.Wrap(err, "fetch usd rate")
 	}
@@ -39,7 +50,7 @@ func (c *Client) USDRate(timestamp time.Time) (float64, error) {
 		return 0, errors.Wrap(err, "read rate response")
 	}
 	if resp.StatusCode != http.StatusOK {
-		return 0, errors.Wrap(err, fmt.Sprintf("unexpected http code %v", resp.StatusCode))
+		return 0, tokenrate.NewUpstreamError(resp)
 	}
 	var rateResp = common.PriceResponse{}
 	err = json.Unmarshal(data, &rateResp)
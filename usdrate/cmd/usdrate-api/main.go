@@ -3,21 +3,33 @@ package main
 import (
 	"log"
 	"os"
+	"time"
 
 	"github.com/urfave/cli"
 
 	"github.com/KyberNetwork/tokenrate"
+	"github.com/KyberNetwork/tokenrate/breaker"
+	"github.com/KyberNetwork/tokenrate/coalesce"
+	"github.com/KyberNetwork/tokenrate/coinbase"
 	"github.com/KyberNetwork/tokenrate/coingecko"
 	"github.com/KyberNetwork/tokenrate/coinlib"
+	"github.com/KyberNetwork/tokenrate/common"
+	"github.com/KyberNetwork/tokenrate/kraken"
 	"github.com/KyberNetwork/tokenrate/pkg/app"
+	"github.com/KyberNetwork/tokenrate/ratelimit"
+	"github.com/KyberNetwork/tokenrate/retry"
 	"github.com/KyberNetwork/tokenrate/usdrate/server"
 	"github.com/KyberNetwork/tokenrate/usdrate/storage"
 )
 
 const (
-	bindAddressFlag = "bindAddress"
+	bindAddressFlag     = "bindAddress"
+	providerTimeoutFlag = "provider-timeout"
+	hedgeDelayFlag      = "hedge-delay"
 
-	defaultBindAddress = "127.0.0.1:8000"
+	defaultBindAddress     = "127.0.0.1:8000"
+	defaultProviderTimeout = time.Second * 10
+	defaultHedgeDelay      = time.Second
 )
 
 func main() {
@@ -34,11 +46,29 @@ func main() {
 			Value:  defaultBindAddress,
 			EnvVar: "BIND_ADDRESS",
 		},
+		cli.DurationFlag{
+			Name:   providerTimeoutFlag,
+			Usage:  "timeout of each provider query before falling back to the next provider",
+			Value:  defaultProviderTimeout,
+			EnvVar: "PROVIDER_TIMEOUT",
+		},
+		cli.DurationFlag{
+			Name:   hedgeDelayFlag,
+			Usage:  "delay before also querying the next provider for today price if the former has not answered",
+			Value:  defaultHedgeDelay,
+			EnvVar: "HEDGE_DELAY",
+		},
 	)
 
 	a.Flags = append(a.Flags, app.NewPostgreSQLFlags("tokenrate")...)
 	a.Flags = append(a.Flags, app.NewSentryFlags()...)
+	a.Flags = append(a.Flags, coingecko.NewFlags()...)
 	a.Flags = append(a.Flags, coinlib.NewFlags()...)
+	a.Flags = append(a.Flags, coinbase.NewFlags()...)
+	a.Flags = append(a.Flags, kraken.NewFlags()...)
+	a.Flags = append(a.Flags, ratelimit.NewFlags()...)
+	a.Flags = append(a.Flags, retry.NewFlags()...)
+	a.Flags = append(a.Flags, breaker.NewFlags()...)
 	if err := a.Run(os.Args); err != nil {
 		log.Fatal(err)
 	}
@@ -55,10 +85,40 @@ func run(c *cli.Context) error {
 		sugar.Errorw("failed to init storage", "error", err)
 		return err
 	}
-	currentPriceProviders := []tokenrate.ETHUSDRateProvider{
+	var (
+		limiters    = ratelimit.NewLimitersFromContext(c)
+		retryOpts   = retry.NewOptionsFromContext(c)
+		breakerOpts = breaker.NewOptionsFromContext(c)
+		breakers    []*breaker.Breaker
+	)
+	breakerOpts.OnStateChange = func(name string, from, to breaker.State) {
+		sugar.Warnw("circuit breaker state changed", "provider", name, "from", from.String(), "to", to.String())
+	}
+	var currentPriceProviders []tokenrate.ETHUSDRateProvider
+	for _, p := range []tokenrate.ETHUSDRateProvider{
 		coingecko.NewCoinGeckoFromContext(c),
-		coinlib.NewCoinLibFromContext(c)}
-	sv := server.NewServer(sugar, c.String(bindAddressFlag), s, currentPriceProviders)
+		coinlib.NewCoinLibFromContext(c),
+		coinbase.NewCoinbaseFromContext(c),
+	} {
+		b := breaker.New(p.Name(), breakerOpts)
+		breakers = append(breakers, b)
+		// concurrent identical queries share one query through breaker, retry and rate limit
+		currentPriceProviders = append(currentPriceProviders,
+			coalesce.NewETHUSDRateProvider(
+				breaker.NewETHUSDRateProvider(
+					retry.NewETHUSDRateProvider(limiters.WrapETHUSD(p), retryOpts), b)))
+	}
+	// kraken is the source of ETH/EUR price
+	krakenBreaker := breaker.New(common.Kraken, breakerOpts)
+	breakers = append(breakers, krakenBreaker)
+	eurProvider := coalesce.New(
+		breaker.NewProvider(
+			retry.New(limiters.Wrap(kraken.NewKrakenFromContext(c)), retryOpts), krakenBreaker))
+	sv := server.NewServer(sugar, c.String(bindAddressFlag), s, currentPriceProviders,
+		server.WithCurrency(common.EURID, eurProvider),
+		server.WithBreakers(breakers...),
+		server.WithProviderTimeout(c.Duration(providerTimeoutFlag)),
+		server.WithHedgeDelay(c.Duration(hedgeDelayFlag)))
 	sugar.Infow("usdrate-api started")
 	return sv.Start()
 }
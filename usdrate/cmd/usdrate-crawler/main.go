@@ -15,9 +15,16 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/KyberNetwork/tokenrate"
+	"github.com/KyberNetwork/tokenrate/binance"
+	"github.com/KyberNetwork/tokenrate/coinbase"
 	"github.com/KyberNetwork/tokenrate/coingecko"
+	"github.com/KyberNetwork/tokenrate/coinmarketcap"
 	"github.com/KyberNetwork/tokenrate/common"
+	"github.com/KyberNetwork/tokenrate/cryptocompare"
+	"github.com/KyberNetwork/tokenrate/kraken"
 	"github.com/KyberNetwork/tokenrate/pkg/app"
+	"github.com/KyberNetwork/tokenrate/ratelimit"
+	"github.com/KyberNetwork/tokenrate/retry"
 	"github.com/KyberNetwork/tokenrate/usdrate/storage"
 )
 
@@ -56,13 +63,21 @@ func main() {
 		},
 		cli.StringFlag{
 			Name:   providerFlag,
-			Usage:  "provide provider to get price [coingecko]",
+			Usage:  "provide provider to get price [coingecko, binance, coinbase, cryptocompare, coinmarketcap, kraken]",
 			EnvVar: "PROVIDER",
 		},
 	)
 	defaultPGDB := "tokenrate"
 	a.Flags = append(a.Flags, app.NewPostgreSQLFlags(defaultPGDB)...)
 	a.Flags = append(a.Flags, app.NewSentryFlags()...)
+	a.Flags = append(a.Flags, coingecko.NewFlags()...)
+	a.Flags = append(a.Flags, binance.NewFlags()...)
+	a.Flags = append(a.Flags, coinbase.NewFlags()...)
+	a.Flags = append(a.Flags, cryptocompare.NewFlags()...)
+	a.Flags = append(a.Flags, coinmarketcap.NewFlags()...)
+	a.Flags = append(a.Flags, kraken.NewFlags()...)
+	a.Flags = append(a.Flags, ratelimit.NewFlags()...)
+	a.Flags = append(a.Flags, retry.NewFlags()...)
 	if err := a.Run(os.Args); err != nil {
 		log.Fatal(err)
 	}
@@ -104,6 +119,16 @@ func NewPriceProvider(c *cli.Context, provider string) (tokenrate.ETHUSDRateProv
 	switch provider {
 	case common.Coingecko:
 		return coingecko.NewCoinGeckoFromContext(c), nil
+	case common.Binance:
+		return binance.NewBinanceFromContext(c), nil
+	case common.Coinbase:
+		return coinbase.NewCoinbaseFromContext(c), nil
+	case common.CryptoCompare:
+		return cryptocompare.NewCryptoCompareFromContext(c), nil
+	case common.CoinMarketCap:
+		return coinmarketcap.NewCoinMarketCapFromContext(c), nil
+	case common.Kraken:
+		return kraken.NewKrakenFromContext(c), nil
 	default:
 		return nil, fmt.Errorf("invalide provider provider=%s", provider)
 	}
@@ -113,6 +138,7 @@ func NewPriceProvider(c *cli.Context, provider string) (tokenrate.ETHUSDRateProv
 func AllProvider(c *cli.Context) []tokenrate.ETHUSDRateProvider {
 	return []tokenrate.ETHUSDRateProvider{
 		coingecko.NewCoinGeckoFromContext(c),
+		cryptocompare.NewCryptoCompareFromContext(c),
 	}
 }
 
@@ -138,6 +164,10 @@ func run(c *cli.Context) error {
 	} else {
 		ps = AllProvider(c)
 	}
+	policy := queryPolicy{
+		limiters:  ratelimit.NewLimitersFromContext(c),
+		retryOpts: retry.NewOptionsFromContext(c),
+	}
 	s, err := storage.NewStorageFromContext(sugar, c)
 	if err != nil {
 		sugar.Errorw("failed to init storage", "error", err)
@@ -156,30 +186,62 @@ func run(c *cli.Context) error {
 		return errors.Wrap(err, "invalid time")
 	}
 
+	// ctx is cancelled on interrupt signal so in-flight queries are abandoned.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cs := make(chan os.Signal, 1)
+	signal.Notify(cs, os.Interrupt)
+	go func() {
+		<-cs
+		cancel()
+	}()
+
 	if len(toTimeS) != 0 {
-		return crawlTokenPriceWithTimeRange(sugar, fromTime, toTime, ps, s)
+		return crawlTokenPriceWithTimeRange(ctx, sugar, fromTime, toTime, ps, policy, s)
 	}
 	logger.Info("to-time is blank, get history price from from-time and run get price daily...")
-	if err := crawlTokenPriceWithTimeRange(sugar, fromTime, toTime, ps, s); err != nil {
+	if err := crawlTokenPriceWithTimeRange(ctx, sugar, fromTime, toTime, ps, policy, s); err != nil {
 		logger.Errorw("failed to get rate with time range", "from-time", fromTime, "to-time", toTime)
 		return err
 	}
-	if err := crawlTokenPriceDaily(sugar, ps, s, c.String(jobRunningTimeFlag)); err != nil {
+	if err := crawlTokenPriceDaily(ctx, sugar, ps, policy, s, c.String(jobRunningTimeFlag)); err != nil {
 		logger.Panicw("failed to get rate daily", "error", err)
 	}
-	cs := make(chan os.Signal, 1)
-	signal.Notify(cs, os.Interrupt)
-	<-cs
+	<-ctx.Done()
 	logger.Info("got interrupt signal, program exited")
 	return nil
 }
 
+// queryPolicy is the rate limit and retry policy of provider queries.
+type queryPolicy struct {
+	limiters  ratelimit.Limiters
+	retryOpts retry.Options
+}
+
+// wrap returns given provider with rate limit and retry applied.
+func (qp queryPolicy) wrap(p tokenrate.ETHUSDRateProvider) tokenrate.ETHUSDRateProvider {
+	return retry.NewETHUSDRateProvider(qp.limiters.WrapETHUSD(p), qp.retryOpts)
+}
+
+// do runs query fn to provider with given name with rate limit and
+// retry applied, for queries that are not made through wrap.
+func (qp queryPolicy) do(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+	return retry.Do(ctx, qp.retryOpts, func(ctx context.Context) error {
+		if err := qp.limiters.Wait(ctx, name); err != nil {
+			return err
+		}
+		return fn(ctx)
+	})
+}
+
 func crawlTokenPriceWithTimeRange(
+	ctx context.Context,
 	sugar *zap.SugaredLogger,
 	fromTime, toTime time.Time,
 	ps []tokenrate.ETHUSDRateProvider,
+	policy queryPolicy,
 	s storage.Storage) error {
-	eg, _ := errgroup.WithContext(context.Background())
+	eg, ctx := errgroup.WithContext(ctx)
 	sugar.Infow("fetch historical price in range", "from", fromTime, "to", toTime)
 	for _, p := range ps {
 		var (
@@ -187,9 +249,17 @@ func crawlTokenPriceWithTimeRange(
 			pLogger = sugar.With("provider", p.Name())
 		)
 		eg.Go(func() error {
+			if cp, ok := p.(tokenrate.ETHUSDCandleProvider); ok {
+				// the failure is logged, prices are still crawled
+				_ = crawlCandles(ctx, pLogger, fromTime, toTime, cp, policy, s)
+			}
+			if rp, ok := p.(tokenrate.ETHUSDRangeProvider); ok {
+				return crawlTokenPriceWithRangeProvider(ctx, pLogger, fromTime, toTime, rp, policy, s)
+			}
+			p := policy.wrap(p)
 			for t := fromTime; t.Sub(toTime) <= 0; t = t.Add(24 * time.Hour) {
 				pLogger.Infow("fetch price", "date", common.TimeToDateString(t))
-				price, err := p.USDRate(t)
+				price, err := tokenrate.USDRateContext(ctx, p, t)
 				if err != nil {
 					pLogger.Errorw("failed to get token price", "error", err)
 					return err
@@ -201,8 +271,6 @@ func crawlTokenPriceWithTimeRange(
 					return err
 				}
 				pLogger.Infow("save token price successfully", "date", common.TimeToDateString(t))
-				// avoid rate limit
-				time.Sleep(time.Millisecond * 200)
 			}
 			return nil
 		})
@@ -213,7 +281,85 @@ func crawlTokenPriceWithTimeRange(
 	return nil
 }
 
-func crawlTokenPriceDaily(logger *zap.SugaredLogger, ps []tokenrate.ETHUSDRateProvider, s storage.Storage, jobRunningTime string) error {
+// crawlTokenPriceWithRangeProvider fetches the whole time range in one
+// query and stores the sample nearest to start of each day.
+func crawlTokenPriceWithRangeProvider(
+	ctx context.Context,
+	logger *zap.SugaredLogger,
+	fromTime, toTime time.Time,
+	p tokenrate.ETHUSDRangeProvider,
+	policy queryPolicy,
+	s storage.Storage) error {
+	// the samples nearest to start of from and to days might be on the other side of the range
+	const margin = 12 * time.Hour
+	logger.Infow("fetch price range", "from", common.TimeToDateString(fromTime), "to", common.TimeToDateString(toTime))
+	var points []tokenrate.RatePoint
+	err := policy.do(ctx, p.Name(), func(ctx context.Context) error {
+		var err error
+		points, err = p.USDRateRange(ctx, fromTime.Add(-margin), toTime.Add(margin))
+		return err
+	})
+	if err != nil {
+		logger.Errorw("failed to get token price range", "error", err)
+		return err
+	}
+	for t := fromTime; t.Sub(toTime) <= 0; t = t.Add(24 * time.Hour) {
+		point, ok := tokenrate.NearestPoint(points, t)
+		if !ok || absDuration(point.Timestamp.Sub(t)) > margin {
+			logger.Errorw("no token price sample for date", "date", common.TimeToDateString(t))
+			return fmt.Errorf("no token price sample for date %s", common.TimeToDateString(t))
+		}
+		logger.Infow("get token price", "time", t, "sample time", point.Timestamp, "price", point.Rate)
+		if err := s.SaveTokenPrice(common.ETHID, common.USDID, p.Name(), t, point.Rate); err != nil {
+			logger.Errorw("failed to save rate to DB", "err", err)
+			return err
+		}
+		logger.Infow("save token price successfully", "date", common.TimeToDateString(t))
+	}
+	return nil
+}
+
+// crawlCandles fetches and stores the daily candles of the time range.
+func crawlCandles(
+	ctx context.Context,
+	logger *zap.SugaredLogger,
+	fromTime, toTime time.Time,
+	p tokenrate.ETHUSDCandleProvider,
+	policy queryPolicy,
+	s storage.Storage) error {
+	logger.Infow("fetch candles", "from", common.TimeToDateString(fromTime), "to", common.TimeToDateString(toTime))
+	var candles []tokenrate.Candle
+	err := policy.do(ctx, p.Name(), func(ctx context.Context) error {
+		var err error
+		candles, err = p.USDCandles(ctx, fromTime, toTime)
+		return err
+	})
+	if err != nil {
+		logger.Errorw("failed to get candles", "error", err)
+		return err
+	}
+	if err := s.SaveCandles(common.ETHID, common.USDID, p.Name(), candles); err != nil {
+		logger.Errorw("failed to save candles to DB", "err", err)
+		return err
+	}
+	logger.Infow("save candles successfully", "candles", len(candles))
+	return nil
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+func crawlTokenPriceDaily(
+	ctx context.Context,
+	logger *zap.SugaredLogger,
+	ps []tokenrate.ETHUSDRateProvider,
+	policy queryPolicy,
+	s storage.Storage,
+	jobRunningTime string) error {
 	if _, err := time.Parse("15:04:05", jobRunningTime); err != nil {
 		return err
 	}
@@ -221,7 +367,7 @@ func crawlTokenPriceDaily(logger *zap.SugaredLogger, ps []tokenrate.ETHUSDRatePr
 		logger.Info("Running job")
 		var now = time.Now().UTC().Add(-time.Hour * 24) // we update token price of the day just passed.
 		for _, p := range ps {
-			price, err := p.USDRate(now)
+			price, err := tokenrate.USDRateContext(ctx, policy.wrap(p), now)
 			if err != nil {
 				logger.Errorw("failed to get token price", "error", err,
 					"provider", p.Name(), "date", common.TimeToDateString(now))
@@ -233,7 +379,10 @@ func crawlTokenPriceDaily(logger *zap.SugaredLogger, ps []tokenrate.ETHUSDRatePr
 			} else {
 				logger.Infow("save token price successfully", "provider", p.Name(), "date", common.TimeToDateString(now))
 			}
-
+			if cp, ok := p.(tokenrate.ETHUSDCandleProvider); ok {
+				// the failure is logged, prices of other providers are still crawled
+				_ = crawlCandles(ctx, logger.With("provider", p.Name()), now, now, cp, policy, s)
+			}
 		}
 	}
 	// run job get price daily
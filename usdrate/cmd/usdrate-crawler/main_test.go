@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/tokenrate"
+	"github.com/KyberNetwork/tokenrate/common"
+	"github.com/KyberNetwork/tokenrate/pkg/testutil"
+)
+
+type candleProvider struct {
+	err error
+}
+
+func (c candleProvider) USDRate(timestamp time.Time) (float64, error) {
+	return 100.0, nil
+}
+
+func (c candleProvider) USDCandles(ctx context.Context, from, to time.Time) ([]tokenrate.Candle, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	var candles []tokenrate.Candle
+	for t := from; !t.After(to); t = t.Add(24 * time.Hour) {
+		candles = append(candles, tokenrate.Candle{Timestamp: t, Open: 99, High: 101, Low: 98, Close: 100, Volume: 1000})
+	}
+	return candles, nil
+}
+
+func (c candleProvider) Name() string {
+	return "candleProvider"
+}
+
+type memStorage struct {
+	prices  map[time.Time]float64
+	candles []tokenrate.Candle
+}
+
+func (m *memStorage) SaveTokenPrice(token, currency, provider string, timestamp time.Time, price float64) error {
+	m.prices[timestamp] = price
+	return nil
+}
+
+func (m *memStorage) GetTokenPrice(token, currency, provider string, timestamp time.Time) (float64, error) {
+	return m.prices[timestamp], nil
+}
+
+func (m *memStorage) SaveCandles(token, currency, provider string, candles []tokenrate.Candle) error {
+	m.candles = append(m.candles, candles...)
+	return nil
+}
+
+func (m *memStorage) GetCandles(token, currency, provider string, from, to time.Time) ([]tokenrate.Candle, error) {
+	return m.candles, nil
+}
+
+func TestCrawlSavesCandles(t *testing.T) {
+	var (
+		from = time.Date(2019, 10, 1, 0, 0, 0, 0, time.UTC)
+		to   = from.Add(2 * 24 * time.Hour)
+		s    = &memStorage{prices: make(map[time.Time]float64)}
+	)
+	err := crawlTokenPriceWithTimeRange(context.Background(), testutil.MustNewDevelopmentSugaredLogger(),
+		from, to, []tokenrate.ETHUSDRateProvider{candleProvider{}}, queryPolicy{}, s)
+	require.NoError(t, err)
+	assert.Len(t, s.prices, 3)
+	require.Len(t, s.candles, 3)
+	assert.Equal(t, common.TimeToDateString(from), common.TimeToDateString(s.candles[0].Timestamp))
+	assert.Equal(t, common.TimeToDateString(to), common.TimeToDateString(s.candles[2].Timestamp))
+}
+
+func TestCrawlCandlesFailureKeepsPrices(t *testing.T) {
+	var (
+		from = time.Date(2019, 10, 1, 0, 0, 0, 0, time.UTC)
+		to   = from.Add(2 * 24 * time.Hour)
+		s    = &memStorage{prices: make(map[time.Time]float64)}
+		p    = candleProvider{err: errors.New("candles not available")}
+	)
+	err := crawlTokenPriceWithTimeRange(context.Background(), testutil.MustNewDevelopmentSugaredLogger(),
+		from, to, []tokenrate.ETHUSDRateProvider{p}, queryPolicy{}, s)
+	require.NoError(t, err)
+	assert.Len(t, s.prices, 3)
+	assert.Empty(t, s.candles)
+}
@@ -1,35 +1,110 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
 
 	"github.com/KyberNetwork/tokenrate"
+	"github.com/KyberNetwork/tokenrate/breaker"
 	"github.com/KyberNetwork/tokenrate/common"
 	"github.com/KyberNetwork/tokenrate/usdrate/storage"
 	"github.com/KyberNetwork/tokenrate/usdrate/storage/postgres"
 )
 
+// defaultHedgeDelay is the default delay before also querying the next
+// provider for today price.
+const defaultHedgeDelay = time.Second
+
 // Server serve token price via http endpoint
 type Server struct {
-	storage   storage.Storage
-	host      string
-	sugar     *zap.SugaredLogger
-	providers []tokenrate.ETHUSDRateProvider
-	r         *gin.Engine
+	storage    storage.Storage
+	host       string
+	sugar      *zap.SugaredLogger
+	currencies map[string]*quoteProviders
+	timeout    time.Duration
+	hedgeDelay time.Duration
+	breakers   []*breaker.Breaker
+	r          *gin.Engine
+}
+
+// Option configures optional behaviour of Server.
+type Option func(*Server)
+
+// WithBreakers reports the state of given circuit breakers of the
+// providers at admin endpoint.
+func WithBreakers(breakers ...*breaker.Breaker) Option {
+	return func(s *Server) {
+		s.breakers = append(s.breakers, breakers...)
+	}
+}
+
+// WithProviderTimeout sets the timeout of each provider query before
+// falling back to the next provider.
+func WithProviderTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		s.timeout = timeout
+	}
+}
+
+// WithHedgeDelay sets the delay before also querying the next provider
+// for today price if the former has not answered.
+func WithHedgeDelay(delay time.Duration) Option {
+	return func(s *Server) {
+		s.hedgeDelay = delay
+	}
+}
+
+// quoteProviders are the providers of ETH price in a currency.
+type quoteProviders struct {
+	providers []tokenrate.Provider
+	// source is the provider of the stored historical prices.
+	source   string
+	fallback *tokenrate.FallbackProvider
+	hedged   *tokenrate.HedgedProvider
+}
+
+// WithCurrency serves ETH price in given currency, e.g. EUR, from given
+// providers at /price/eth-<currency>. Historical prices are stored as
+// of the first provider.
+func WithCurrency(currency string, providers ...tokenrate.Provider) Option {
+	return func(s *Server) {
+		if len(providers) == 0 {
+			return
+		}
+		s.currencies[strings.ToUpper(currency)] = &quoteProviders{
+			providers: providers,
+			source:    providers[0].Name(),
+		}
+	}
 }
 
 // NewServer return server instance
-func NewServer(sugar *zap.SugaredLogger, host string, storage storage.Storage, providers []tokenrate.ETHUSDRateProvider) *Server {
+func NewServer(sugar *zap.SugaredLogger, host string, storage storage.Storage, providers []tokenrate.ETHUSDRateProvider, opts ...Option) *Server {
 	s := &Server{
-		storage:   storage,
-		host:      host,
-		sugar:     sugar,
-		providers: providers,
+		storage:    storage,
+		host:       host,
+		sugar:      sugar,
+		currencies: make(map[string]*quoteProviders),
+		hedgeDelay: defaultHedgeDelay,
+	}
+	ps := make([]tokenrate.Provider, 0, len(providers))
+	for _, p := range providers {
+		ps = append(ps, tokenrate.FromETHUSDRateProvider(p))
+	}
+	s.currencies[common.USDID] = &quoteProviders{providers: ps, source: common.Coingecko}
+	for _, opt := range opts {
+		opt(s)
+	}
+	for _, qp := range s.currencies {
+		qp.fallback = tokenrate.NewFallbackProvider(qp.providers, tokenrate.WithTimeout(s.timeout))
+		qp.hedged = tokenrate.NewHedgedProvider(qp.providers, s.hedgeDelay, tokenrate.WithHedgedTimeout(s.timeout))
 	}
 	r := s.setupRouter()
 	s.r = r
@@ -40,19 +115,28 @@ type queryPrice struct {
 	Date string `form:"date"`
 }
 
-func (s *Server) currentPrice(t time.Time) (float64, error) {
-	s.sugar.Infow("resolve current price", "date", t)
-	for _, p := range s.providers {
-		v, err := p.USDRate(t)
-		if err == nil {
-			return v, nil
+func (s *Server) currentPrice(ctx context.Context, currency string, t time.Time) (float64, error) {
+	s.sugar.Infow("resolve current price", "currency", currency, "date", t)
+	v, source, err := s.currencies[currency].hedged.RateWithSource(ctx, common.ETHID, currency, t)
+	s.logFallbackErrors(err)
+	if err != nil {
+		return 0, err
+	}
+	s.sugar.Infow("resolved current price", "provider", source)
+	return v, nil
+}
+
+// logFallbackErrors logs the failure of each provider of a fallback
+// query.
+func (s *Server) logFallbackErrors(err error) {
+	if fallbackErr, ok := err.(*tokenrate.FallbackError); ok {
+		for _, pErr := range fallbackErr.Errors {
+			s.sugar.Warnw("query price failed, try next", "provider", pErr.Provider, "err", pErr.Err)
 		}
-		s.sugar.Warnw("query today price failed, try next", "provider", p.Name(), "err", err)
 	}
-	return 0, fmt.Errorf("get current ETH price failed after all try")
 }
 
-func (s *Server) receiveETHUSDPrice(date string) (float64, error) {
+func (s *Server) receiveETHPrice(ctx context.Context, currency, date string) (float64, error) {
 	ts := common.TimeOfTodayStart()
 	if date == "" {
 		date = common.TimeToDateString(time.Now().UTC())
@@ -66,59 +150,141 @@ func (s *Server) receiveETHUSDPrice(date string) (float64, error) {
 	}
 
 	if queryDate == ts { // query for today price
-		return s.currentPrice(queryDate)
+		return s.currentPrice(ctx, currency, queryDate)
 	}
 
-	s.sugar.Infow("query price from DB", "date", date)
+	qp := s.currencies[currency]
+	s.sugar.Infow("query price from DB", "currency", currency, "date", date)
 	// query historical data, fetch it from DB, fallover to provider if DB say not found
-	v, err := s.storage.GetTokenPrice(common.ETHID, common.USDID, common.Coingecko, queryDate)
-	if err == postgres.ErrNotFound && len(s.providers) > 0 {
-		s.sugar.Warnw("DB return not found, fallback to request to provider", "date", queryDate)
-		for _, p := range s.providers {
-			if v, err = p.USDRate(queryDate); err == nil {
-				// store it so we dont have to query to provider later.
-				if err = s.storage.SaveTokenPrice(common.ETHID, common.USDID, p.Name(), queryDate, v); err != nil {
-					s.sugar.Warnw("store rate failed", "err", err)
-				}
-				return v, nil
+	v, err := s.storage.GetTokenPrice(common.ETHID, currency, qp.source, queryDate)
+	if errors.Cause(err) == postgres.ErrNotFound && len(qp.providers) > 0 {
+		s.sugar.Warnw("DB return not found, fallback to request to provider", "currency", currency, "date", queryDate)
+		var source string
+		v, source, err = qp.fallback.RateWithSource(ctx, common.ETHID, currency, queryDate)
+		s.logFallbackErrors(err)
+		if err == nil {
+			s.sugar.Infow("resolved historical price", "provider", source)
+			// store it under the source read above, so we dont have to
+			// query to provider later whichever provider answered.
+			if err = s.storage.SaveTokenPrice(common.ETHID, currency, qp.source, queryDate, v); err != nil {
+				s.sugar.Warnw("store rate failed", "err", err)
 			}
+			return v, nil
 		}
 	}
 	return v, err
 }
 
-func (s *Server) getETHUSDPrice(c *gin.Context) {
+// getETHPrice returns the handler of ETH price in given currency.
+func (s *Server) getETHPrice(currency string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var (
+			query queryPrice
+		)
+		resp := common.PriceResponse{
+			Token:    "ETH",
+			Currency: currency,
+			Failed:   false,
+			Error:    "",
+			Price:    0,
+		}
+		if err := c.ShouldBindQuery(&query); err != nil {
+			resp.Failed = true
+			resp.Error = err.Error()
+			c.JSONP(http.StatusOK, resp)
+			return
+		}
+		price, err := s.receiveETHPrice(c.Request.Context(), currency, query.Date)
+		if err != nil {
+			resp.Failed = true
+			resp.Error = err.Error()
+			c.JSONP(http.StatusOK, resp)
+			return
+		}
+		resp.Price = price
+		resp.Failed = false
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+type queryCandles struct {
+	From string `form:"from" binding:"required"`
+	To   string `form:"to"`
+}
+
+func (s *Server) receiveETHUSDCandles(from, to string) ([]common.Candle, error) {
+	fromTime, err := common.DateStringToTime(from)
+	if err != nil {
+		return nil, err
+	}
+	toTime := common.TimeOfTodayStart()
+	if to != "" {
+		if toTime, err = common.DateStringToTime(to); err != nil {
+			return nil, err
+		}
+	}
+	if toTime.Before(fromTime) {
+		return nil, fmt.Errorf("from %s is after to %s", from, to)
+	}
+	candles, err := s.storage.GetCandles(common.ETHID, common.USDID, common.Coingecko, fromTime, toTime)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]common.Candle, 0, len(candles))
+	for _, c := range candles {
+		result = append(result, common.Candle{
+			Date:   common.TimeToDateString(c.Timestamp),
+			Open:   c.Open,
+			High:   c.High,
+			Low:    c.Low,
+			Close:  c.Close,
+			Volume: c.Volume,
+		})
+	}
+	return result, nil
+}
+
+func (s *Server) getETHUSDCandles(c *gin.Context) {
 	var (
-		query queryPrice
+		query queryCandles
 	)
-	resp := common.PriceResponse{
+	resp := common.CandlesResponse{
 		Token:    "ETH",
 		Currency: "USD",
-		Failed:   false,
-		Error:    "",
-		Price:    0,
+		Candles:  []common.Candle{},
 	}
 	if err := c.ShouldBindQuery(&query); err != nil {
 		resp.Failed = true
 		resp.Error = err.Error()
-		c.JSONP(http.StatusOK, resp)
+		c.JSON(http.StatusOK, resp)
 		return
 	}
-	price, err := s.receiveETHUSDPrice(query.Date)
+	candles, err := s.receiveETHUSDCandles(query.From, query.To)
 	if err != nil {
 		resp.Failed = true
 		resp.Error = err.Error()
-		c.JSONP(http.StatusOK, resp)
+		c.JSON(http.StatusOK, resp)
 		return
 	}
-	resp.Price = price
-	resp.Failed = false
+	resp.Candles = candles
 	c.JSON(http.StatusOK, resp)
 }
 
+func (s *Server) getBreakers(c *gin.Context) {
+	statuses := make([]breaker.Status, 0, len(s.breakers))
+	for _, b := range s.breakers {
+		statuses = append(statuses, b.Status())
+	}
+	c.JSON(http.StatusOK, statuses)
+}
+
 func (s *Server) setupRouter() *gin.Engine {
 	r := gin.Default()
-	r.GET("/price/eth-usd", s.getETHUSDPrice)
+	for currency := range s.currencies {
+		r.GET("/price/eth-"+strings.ToLower(currency), s.getETHPrice(currency))
+	}
+	r.GET("/candles/eth-usd", s.getETHUSDCandles)
+	r.GET("/admin/breakers", s.getBreakers)
 	return r
 }
 
@@ -1,18 +1,22 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
+	pkgerrors "github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 
 	"github.com/KyberNetwork/tokenrate"
+	"github.com/KyberNetwork/tokenrate/breaker"
 	"github.com/KyberNetwork/tokenrate/common"
 	"github.com/KyberNetwork/tokenrate/pkg/testutil"
 	"github.com/KyberNetwork/tokenrate/usdrate/storage/postgres"
@@ -29,6 +33,17 @@ func (n notAvailableRate) Name() string {
 	return "N/A"
 }
 
+type unreachableRate struct {
+}
+
+func (u unreachableRate) USDRate(time.Time) (float64, error) {
+	return 0, &url.Error{Op: "Get", URL: "http://unreachable", Err: errors.New("connection refused")}
+}
+
+func (u unreachableRate) Name() string {
+	return "N/A"
+}
+
 type fixedRate struct {
 }
 
@@ -61,3 +76,219 @@ func TestClient(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 100.0, rate.Price)
 }
+
+func TestBreakersEndpoint(t *testing.T) {
+	b := breaker.New("N/A", breaker.Options{FailureThreshold: 1, Cooldown: time.Minute})
+	p := breaker.NewETHUSDRateProvider(unreachableRate{}, b)
+	s := NewServer(zap.S(), "localhost:8080", nil, []tokenrate.ETHUSDRateProvider{p}, WithBreakers(b))
+	_, err := p.USDRate(time.Now())
+	require.Error(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, "/admin/breakers", nil)
+	require.NoError(t, err)
+	resp := httptest.NewRecorder()
+	s.r.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	var statuses []breaker.Status
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&statuses))
+	require.Len(t, statuses, 1)
+	assert.Equal(t, "N/A", statuses[0].Name)
+	assert.Equal(t, breaker.Open.String(), statuses[0].State)
+}
+
+type slowRate struct {
+}
+
+func (s slowRate) USDRate(time.Time) (float64, error) {
+	time.Sleep(time.Second)
+	return 50.0, nil
+}
+
+func (s slowRate) Name() string {
+	return "slowRate"
+}
+
+func TestCurrentPriceHedged(t *testing.T) {
+	s := NewServer(zap.S(), "localhost:8080", nil,
+		[]tokenrate.ETHUSDRateProvider{slowRate{}, fixedRate{}},
+		WithHedgeDelay(time.Millisecond*10))
+	start := time.Now()
+	price, err := s.currentPrice(context.Background(), common.USDID, common.TimeOfTodayStart())
+	require.NoError(t, err)
+	assert.Equal(t, 100.0, price)
+	assert.True(t, time.Since(start) < time.Millisecond*500)
+}
+
+type candleStorage struct {
+	candles []tokenrate.Candle
+}
+
+func (c candleStorage) SaveTokenPrice(token, currency, provider string, timestamp time.Time, price float64) error {
+	return errors.New("not implemented")
+}
+
+func (c candleStorage) GetTokenPrice(token, currency, provider string, timestamp time.Time) (float64, error) {
+	return 0, pkgerrors.Wrap(postgres.ErrNotFound, "no price")
+}
+
+func (c candleStorage) SaveCandles(token, currency, provider string, candles []tokenrate.Candle) error {
+	return errors.New("not implemented")
+}
+
+func (c candleStorage) GetCandles(token, currency, provider string, from, to time.Time) ([]tokenrate.Candle, error) {
+	var result []tokenrate.Candle
+	for _, candle := range c.candles {
+		if !candle.Timestamp.Before(from) && !candle.Timestamp.After(to) {
+			result = append(result, candle)
+		}
+	}
+	return result, nil
+}
+
+func TestCandlesEndpoint(t *testing.T) {
+	day := time.Date(2019, 2, 6, 0, 0, 0, 0, time.UTC)
+	st := candleStorage{candles: []tokenrate.Candle{
+		{Timestamp: day, Open: 100, High: 110, Low: 95, Close: 105, Volume: 1000},
+		{Timestamp: day.Add(24 * time.Hour), Open: 105, High: 120, Low: 104, Close: 118, Volume: 2000},
+	}}
+	s := NewServer(zap.S(), "localhost:8080", st, nil)
+
+	req, err := http.NewRequest(http.MethodGet, "/candles/eth-usd?from=2019-02-06&to=2019-02-06", nil)
+	require.NoError(t, err)
+	resp := httptest.NewRecorder()
+	s.r.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	var candles common.CandlesResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&candles))
+	assert.False(t, candles.Failed)
+	assert.Equal(t, []common.Candle{
+		{Date: "2019-02-06", Open: 100, High: 110, Low: 95, Close: 105, Volume: 1000},
+	}, candles.Candles)
+
+	req, err = http.NewRequest(http.MethodGet, "/candles/eth-usd?from=2019-02-07&to=2019-02-06", nil)
+	require.NoError(t, err)
+	resp = httptest.NewRecorder()
+	s.r.ServeHTTP(resp, req)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&candles))
+	assert.True(t, candles.Failed)
+}
+
+type fixedEURRate struct {
+}
+
+func (f fixedEURRate) Rate(token, currency string, timestamp time.Time) (float64, error) {
+	if token != common.ETHID || currency != common.EURID {
+		return 0, tokenrate.ErrUnsupportedCurrency
+	}
+	return 90.0, nil
+}
+
+func (f fixedEURRate) Name() string {
+	return "fixedEURRate"
+}
+
+func TestCurrencyEndpoint(t *testing.T) {
+	s := NewServer(zap.S(), "localhost:8080", nil,
+		[]tokenrate.ETHUSDRateProvider{fixedRate{}},
+		WithCurrency("eur", fixedEURRate{}))
+
+	for path, expected := range map[string]common.PriceResponse{
+		"/price/eth-usd": {Token: "ETH", Currency: common.USDID, Price: 100.0},
+		"/price/eth-eur": {Token: "ETH", Currency: common.EURID, Price: 90.0},
+	} {
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		require.NoError(t, err)
+		resp := httptest.NewRecorder()
+		s.r.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		var rate common.PriceResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&rate))
+		assert.Equal(t, expected, rate)
+	}
+}
+
+func TestHistoricalPriceNotStored(t *testing.T) {
+	s := NewServer(zap.S(), "localhost:8080", candleStorage{},
+		[]tokenrate.ETHUSDRateProvider{notAvailableRate{}, fixedRate{}})
+	price, err := s.receiveETHPrice(context.Background(), common.USDID, "2019-02-06")
+	require.NoError(t, err)
+	assert.Equal(t, 100.0, price)
+}
+
+type hungRate struct {
+}
+
+func (h hungRate) USDRate(timestamp time.Time) (float64, error) {
+	return h.USDRateContext(context.Background(), timestamp)
+}
+
+func (h hungRate) USDRateContext(ctx context.Context, timestamp time.Time) (float64, error) {
+	<-ctx.Done()
+	return 0, ctx.Err()
+}
+
+func (h hungRate) Name() string {
+	return "hungRate"
+}
+
+func TestCurrentPriceProviderTimeout(t *testing.T) {
+	s := NewServer(zap.S(), "localhost:8080", nil,
+		[]tokenrate.ETHUSDRateProvider{hungRate{}, fixedRate{}},
+		WithHedgeDelay(time.Hour),
+		WithProviderTimeout(time.Millisecond*20))
+	start := time.Now()
+	price, err := s.currentPrice(context.Background(), common.USDID, common.TimeOfTodayStart())
+	require.NoError(t, err)
+	assert.Equal(t, 100.0, price)
+	assert.True(t, time.Since(start) < time.Millisecond*500)
+}
+
+type countingRate struct {
+	calls int
+}
+
+func (c *countingRate) USDRate(time.Time) (float64, error) {
+	c.calls++
+	return 100.0, nil
+}
+
+func (c *countingRate) Name() string {
+	return "countingRate"
+}
+
+type priceStorage struct {
+	candleStorage
+	prices map[string]float64
+}
+
+func (p priceStorage) key(token, currency, provider string, timestamp time.Time) string {
+	return token + currency + provider + common.TimeToDateString(timestamp)
+}
+
+func (p priceStorage) SaveTokenPrice(token, currency, provider string, timestamp time.Time, price float64) error {
+	p.prices[p.key(token, currency, provider, timestamp)] = price
+	return nil
+}
+
+func (p priceStorage) GetTokenPrice(token, currency, provider string, timestamp time.Time) (float64, error) {
+	price, ok := p.prices[p.key(token, currency, provider, timestamp)]
+	if !ok {
+		return 0, pkgerrors.Wrap(postgres.ErrNotFound, "no price")
+	}
+	return price, nil
+}
+
+func TestHistoricalPriceStoredUnderSource(t *testing.T) {
+	var (
+		p  = &countingRate{}
+		st = priceStorage{prices: make(map[string]float64)}
+		s  = NewServer(zap.S(), "localhost:8080", st,
+			[]tokenrate.ETHUSDRateProvider{notAvailableRate{}, p})
+	)
+	for i := 0; i < 2; i++ {
+		price, err := s.receiveETHPrice(context.Background(), common.USDID, "2019-02-06")
+		require.NoError(t, err)
+		assert.Equal(t, 100.0, price)
+	}
+	assert.Equal(t, 1, p.calls)
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/urfave/cli"
 	"go.uber.org/zap"
 
+	"github.com/KyberNetwork/tokenrate"
 	"github.com/KyberNetwork/tokenrate/pkg/app"
 	"github.com/KyberNetwork/tokenrate/usdrate/storage/postgres"
 )
@@ -16,6 +17,10 @@ type Storage interface {
 	SaveTokenPrice(token, currency, provider string, timestamp time.Time, price float64) error
 	// GetTokenPrice ...
 	GetTokenPrice(token, currency, provider string, timestamp time.Time) (float64, error)
+	// SaveCandles ...
+	SaveCandles(token, currency, provider string, candles []tokenrate.Candle) error
+	// GetCandles ...
+	GetCandles(token, currency, provider string, from, to time.Time) ([]tokenrate.Candle, error)
 }
 
 // NewStorageFromContext return storage interface from context
@@ -7,10 +7,13 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
+
+	"github.com/KyberNetwork/tokenrate"
 )
 
 var (
-	// ErrNotFound return when data not found
+	// ErrNotFound is the cause of the error returned when the queried
+	// data is not stored, match it with errors.Cause.
 	ErrNotFound = errors.New("not found")
 )
 
@@ -43,10 +46,27 @@ func initTable(db *sqlx.DB) error {
 				PRIMARY KEY (date, provider, token, currency)
 			);
 		`
+		candlesSchema = `
+			CREATE TABLE IF NOT EXISTS "candles" (
+				date DATE,
+				provider TEXT NOT NULL,
+				token TEXT NOT NULL,
+				currency TEXT NOT NULL,
+				open FLOAT(53) NOT NULL,
+				high FLOAT(53) NOT NULL,
+				low FLOAT(53) NOT NULL,
+				close FLOAT(53) NOT NULL,
+				volume FLOAT(53) NOT NULL,
+				PRIMARY KEY (date, provider, token, currency)
+			);
+		`
 	)
 	if _, err := db.Exec(tokenPricesSchema); err != nil {
 		return err
 	}
+	if _, err := db.Exec(candlesSchema); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -87,10 +107,83 @@ func (x *TokenPriceDB) GetTokenPrice(token, currency, provider string, timestamp
 	)
 	logger.Info("get token price")
 	if err := x.db.Get(&dbResult, query, token, currency, provider, timestamp); err == sql.ErrNoRows {
-		return 0, ErrNotFound
+		return 0, errors.Wrapf(ErrNotFound, "no %s/%s price of %s at %s", token, currency, provider, timestamp.Format("2006-01-02"))
 	} else if err != nil {
 		logger.Errorw("got error from database", "error", err)
-		return 0, errors.New("failed to query token price in database")
+		return 0, errors.Wrap(err, "failed to query token price in database")
 	}
 	return dbResult.Price.Float64, nil
 }
+
+// SaveCandles save daily candles, existing candles of the same days are
+// replaced.
+func (x *TokenPriceDB) SaveCandles(token, currency, provider string, candles []tokenrate.Candle) error {
+	const query = `
+		INSERT INTO "candles"(date, provider, token, currency, open, high, low, close, volume)
+		VALUES (DATE($1), $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (date, provider, token, currency)
+		DO
+		UPDATE SET open=$5, high=$6, low=$7, close=$8, volume=$9;
+		`
+	tx, err := x.db.Beginx()
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	for _, c := range candles {
+		if _, err := tx.Exec(query, c.Timestamp.UTC(), provider, token, currency,
+			c.Open, c.High, c.Low, c.Close, c.Volume); err != nil {
+			return errors.Wrap(err, "failed to store candle to database")
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "failed to store candles to database")
+	}
+	return nil
+}
+
+type candleDB struct {
+	Date   time.Time `db:"date"`
+	Open   float64   `db:"open"`
+	High   float64   `db:"high"`
+	Low    float64   `db:"low"`
+	Close  float64   `db:"close"`
+	Volume float64   `db:"volume"`
+}
+
+// GetCandles returns the daily candles of the days from from to to,
+// inclusive, ordered by date.
+func (x *TokenPriceDB) GetCandles(token, currency, provider string, from, to time.Time) ([]tokenrate.Candle, error) {
+	var (
+		logger = x.sugar.With(
+			"from", from,
+			"to", to,
+			"token", token,
+			"currency", currency,
+		)
+		query = `SELECT date, open, high, low, close, volume FROM "candles"
+			WHERE token=$1 AND currency=$2 AND provider=$3 AND date BETWEEN DATE($4) AND DATE($5)
+			ORDER BY date`
+
+		dbResult []candleDB
+	)
+	logger.Info("get candles")
+	if err := x.db.Select(&dbResult, query, token, currency, provider, from, to); err != nil {
+		logger.Errorw("got error from database", "error", err)
+		return nil, errors.Wrap(err, "failed to query candles in database")
+	}
+	candles := make([]tokenrate.Candle, 0, len(dbResult))
+	for _, c := range dbResult {
+		candles = append(candles, tokenrate.Candle{
+			Timestamp: time.Date(c.Date.Year(), c.Date.Month(), c.Date.Day(), 0, 0, 0, 0, time.UTC),
+			Open:      c.Open,
+			High:      c.High,
+			Low:       c.Low,
+			Close:     c.Close,
+			Volume:    c.Volume,
+		})
+	}
+	return candles, nil
+}
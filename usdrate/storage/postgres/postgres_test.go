@@ -4,8 +4,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 
+	"github.com/KyberNetwork/tokenrate"
 	"github.com/KyberNetwork/tokenrate/pkg/testutil"
 )
 
@@ -43,8 +45,44 @@ func TestSaveNewTokenPrice(t *testing.T) {
 	require.Equal(t, newPrice, newPriceDB)
 
 	_, err = trdb.GetTokenPrice("KNC", currency, coinbase, timestamp)
-	require.EqualError(t, err, ErrNotFound.Error())
+	require.Equal(t, ErrNotFound, errors.Cause(err))
 
 	_, err = trdb.GetTokenPrice("KNC", currency, coingecko, timestamp)
-	require.EqualError(t, err, ErrNotFound.Error())
+	require.Equal(t, ErrNotFound, errors.Cause(err))
+}
+
+func TestSaveCandles(t *testing.T) {
+	db, teardown := testutil.MustNewDevelopmentDB()
+	defer func() {
+		require.NoError(t, teardown())
+	}()
+	sugar := testutil.MustNewDevelopmentSugaredLogger()
+	trdb, err := NewTokenPriceDB(sugar, db)
+	require.NoError(t, err)
+	var (
+		token     = "ETH"
+		currency  = "USD"
+		coingecko = "coingecko"
+		day1      = time.Date(2019, 2, 6, 0, 0, 0, 0, time.UTC)
+		day2      = day1.Add(24 * time.Hour)
+		candles   = []tokenrate.Candle{
+			{Timestamp: day1, Open: 100, High: 110, Low: 95, Close: 105, Volume: 1000},
+			{Timestamp: day2, Open: 105, High: 120, Low: 104, Close: 118, Volume: 2000},
+		}
+	)
+	require.NoError(t, trdb.SaveCandles(token, currency, coingecko, candles))
+
+	candlesDB, err := trdb.GetCandles(token, currency, coingecko, day1, day2)
+	require.NoError(t, err)
+	require.Equal(t, candles, candlesDB)
+
+	candles[1].Close = 119
+	require.NoError(t, trdb.SaveCandles(token, currency, coingecko, candles[1:]))
+	candlesDB, err = trdb.GetCandles(token, currency, coingecko, day2, day2)
+	require.NoError(t, err)
+	require.Equal(t, candles[1:], candlesDB)
+
+	candlesDB, err = trdb.GetCandles("KNC", currency, coingecko, day1, day2)
+	require.NoError(t, err)
+	require.Empty(t, candlesDB)
 }